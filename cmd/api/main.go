@@ -7,6 +7,7 @@ import (
 	"github.com/raihan2bd/vidverse/config"
 	"github.com/raihan2bd/vidverse/handlers"
 	"github.com/raihan2bd/vidverse/handlers/websocket"
+	"github.com/raihan2bd/vidverse/workers"
 )
 
 var app *config.Application
@@ -29,6 +30,16 @@ func main() {
 	socketRepo := websocket.NewAPP(app)
 	websocket.NewSocket(socketRepo)
 	go websocket.Methods.HandleMessages()
+	workerRepo := workers.NewAPP(app)
+	workers.NewWorker(workerRepo)
+	go workers.Methods.RefreshTrending()
+	go workers.Methods.PublishScheduledVideos()
+	go workers.Methods.RollupAnalytics()
+	go workers.Methods.PurgeTrash()
+	go workers.Methods.BuildDataExports()
+	go workers.Methods.PurgeAccounts()
+	go workers.Methods.DeliverWebhooks()
+	workers.Methods.ProcessVideos()
 	r := NewRouter()
 
 	r.Run()
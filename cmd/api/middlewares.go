@@ -1,10 +1,18 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/raihan2bd/vidverse/helpers"
+	"github.com/raihan2bd/vidverse/models"
 )
 
 // pass the user_id if logged in
@@ -15,6 +23,14 @@ func HasToken(c *gin.Context) {
 		return
 	}
 
+	if isAPIKey(token) {
+		if key, _, err := authenticateAPIKey(c, token); err == nil {
+			c.Set("user_id", float64(key.UserID))
+		}
+		c.Next()
+		return
+	}
+
 	claims, err := helpers.DecodeToken(token)
 
 	if err != nil {
@@ -23,12 +39,13 @@ func HasToken(c *gin.Context) {
 		return
 	}
 
-	if !helpers.ValidateToken(claims) {
+	if !helpers.ValidateToken(claims) || !passedRequiredMFA(claims) {
 		c.Next()
 		return
 	}
 
 	c.Set("user_id", claims["sub"])
+	c.Set("mfa", claims["mfa"] == true)
 	c.Next()
 }
 
@@ -39,6 +56,18 @@ func IsLoggedIn(c *gin.Context) {
 		return
 	}
 
+	if isAPIKey(token) {
+		key, status, err := authenticateAPIKey(c, token)
+		if err != nil {
+			c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set("user_id", float64(key.UserID))
+		c.Next()
+		return
+	}
+
 	claims, err := helpers.DecodeToken(token)
 
 	if err != nil {
@@ -51,12 +80,25 @@ func IsLoggedIn(c *gin.Context) {
 		return
 	}
 
+	if !passedRequiredMFA(claims) {
+		c.AbortWithStatusJSON(401, gin.H{"error": "Two-factor authentication is required. Please login again"})
+		return
+	}
+
 	c.Set("user_id", claims["sub"])
+	c.Set("mfa", claims["mfa"] == true)
 	c.Next()
 }
 
 func IsAdmin(c *gin.Context) {
-	claims, err := helpers.DecodeToken(c.Request.Header.Get("Authorization"))
+	token := c.Request.Header.Get("Authorization")
+	if isAPIKey(token) {
+		// API keys skip the two-factor check so they never carry admin rights
+		c.AbortWithStatusJSON(403, gin.H{"error": "this endpoint can not be used with an API key"})
+		return
+	}
+
+	claims, err := helpers.DecodeToken(token)
 
 	if err != nil {
 		c.AbortWithStatus(401)
@@ -73,7 +115,14 @@ func IsAdmin(c *gin.Context) {
 		return
 	}
 
+	// admins must have passed the two-factor check
+	if claims["mfa"] != true {
+		c.AbortWithStatus(403)
+		return
+	}
+
 	c.Set("user_id", claims["sub"])
+	c.Set("mfa", true)
 	c.Next()
 }
 
@@ -83,6 +132,24 @@ func isAuthor(c *gin.Context) {
 		c.Next()
 		return
 	}
+
+	if isAPIKey(token) {
+		key, status, err := authenticateAPIKey(c, token)
+		if err != nil {
+			c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		user, err := app.DBMethods.GetUserByID(key.UserID)
+		if err != nil || (user.UserRole != "author" && user.UserRole != "admin") {
+			c.AbortWithStatusJSON(403, gin.H{"error": "forbidden"})
+			return
+		}
+
+		c.Set("user_id", float64(key.UserID))
+		c.Next()
+		return
+	}
 	claims, err := helpers.DecodeToken(token)
 
 	if err != nil {
@@ -110,6 +177,120 @@ func isAuthor(c *gin.Context) {
 		}
 	}
 
+	if !passedRequiredMFA(claims) {
+		c.AbortWithStatusJSON(401, gin.H{"error": "Two-factor authentication is required. Please login again"})
+		return
+	}
+
 	c.Set("user_id", claims["sub"])
+	c.Set("mfa", claims["mfa"] == true)
+	c.Next()
+}
+
+// admins always login with two-factor authentication, an admin token without it was not issued by the login
+func passedRequiredMFA(claims jwt.MapClaims) bool {
+	return claims["user_role"] != "admin" || claims["mfa"] == true
+}
+
+// limit the requests of an IP with one of the limiters of the app
+func RateLimit(name string) gin.HandlerFunc {
+	return rateLimit(name, func(c *gin.Context) string { return c.ClientIP() })
+}
+
+// limit the requests of a viewer for each video, the user when logged in or else the IP, it goes after HasToken
+func RateLimitPerVideo(name string) gin.HandlerFunc {
+	return rateLimit(name, func(c *gin.Context) string {
+		viewer := "ip:" + c.ClientIP()
+		if id, ok := c.Get("user_id"); ok {
+			viewer = fmt.Sprintf("user:%d", uint(id.(float64)))
+		}
+		return viewer + ":video:" + c.Param("videoID")
+	})
+}
+
+func rateLimit(name string, key func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limiter := app.RateLimiters[name]
+		if limiter == nil {
+			c.Next()
+			return
+		}
+
+		ok, wait := limiter.Allow(key(c))
+		if !ok {
+			seconds := int(math.Ceil(wait.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.AbortWithStatusJSON(429, gin.H{
+				"error": fmt.Sprintf("Too many requests. Please try again in %d seconds", seconds),
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// set the scope an API key needs for a route, it goes before the auth middleware
+func Scope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("api_key_scope", scope)
+		c.Next()
+	}
+}
+
+// keep API keys out of a route whose GET changes data or gives away the whole account, it goes before the auth middleware
+func NoAPIKey(c *gin.Context) {
+	c.Set("api_key_denied", true)
 	c.Next()
 }
+
+// tell an API key from a login token
+func isAPIKey(token string) bool {
+	return strings.HasPrefix(token, models.APIKeyPrefix)
+}
+
+// check an API key and its scope for the route, GET routes only need the read scope
+// and the other routes can only be used with a key when they declare a scope
+func authenticateAPIKey(c *gin.Context, token string) (*models.APIKey, int, error) {
+	key, err := app.DBMethods.GetAPIKeyByHash(helpers.HashToken(token))
+	if err != nil {
+		return nil, 401, err
+	}
+
+	if key.ExpiresAt != nil && key.ExpiresAt.Before(time.Now()) {
+		return nil, 401, errors.New("the API key has expired")
+	}
+
+	err = checkAPIKeyScope(c, key)
+	if err != nil {
+		return nil, 403, err
+	}
+
+	err = app.DBMethods.TouchAPIKey(key.ID)
+	if err != nil {
+		log.Println(err)
+	}
+
+	c.Set("api_key_id", key.ID)
+	return key, 200, nil
+}
+
+// check a key has the scope the route declares, the read scope for the GET routes without one
+func checkAPIKeyScope(c *gin.Context, key *models.APIKey) error {
+	if c.GetBool("api_key_denied") {
+		return errors.New("this endpoint can not be used with an API key")
+	}
+
+	scope := c.GetString("api_key_scope")
+	if scope == "" && (c.Request.Method == "GET" || c.Request.Method == "HEAD") {
+		scope = models.ScopeRead
+	}
+	if scope == "" {
+		return errors.New("this endpoint can not be used with an API key")
+	}
+	if !key.HasScope(scope) {
+		return fmt.Errorf("the API key does not have the %s scope", scope)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihan2bd/vidverse/models"
+)
+
+func TestCheckAPIKeyScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		scope   string
+		denied  bool
+		scopes  string
+		wantErr bool
+	}{
+		{name: "GET needs the read scope", method: "GET", scopes: models.ScopeRead},
+		{name: "GET without the read scope", method: "GET", scopes: models.ScopeUpload, wantErr: true},
+		{name: "declared scope", method: "POST", scope: models.ScopeUpload, scopes: "read,upload"},
+		{name: "declared scope missing", method: "POST", scope: models.ScopeModerate, scopes: "read,upload", wantErr: true},
+		{name: "GET with a declared scope", method: "GET", scope: models.ScopeManageChannel, scopes: models.ScopeRead, wantErr: true},
+		{name: "write route without a scope", method: "DELETE", scopes: "read,upload,manage_channel,moderate", wantErr: true},
+		{name: "denied GET route", method: "GET", denied: true, scopes: "read,upload,manage_channel,moderate", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(tt.method, "/", nil)
+			if tt.scope != "" {
+				c.Set("api_key_scope", tt.scope)
+			}
+			if tt.denied {
+				c.Set("api_key_denied", true)
+			}
+
+			err := checkAPIKeyScope(c, &models.APIKey{Scopes: tt.scopes})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkAPIKeyScope() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,15 +1,26 @@
 package main
 
 import (
+	"log"
+	"os"
+	"strings"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/raihan2bd/vidverse/handlers"
 	"github.com/raihan2bd/vidverse/handlers/websocket"
+	"github.com/raihan2bd/vidverse/models"
 )
 
 func NewRouter() *gin.Engine {
 	r := gin.New()
 
+	// the rate limits and login throttles key on the client IP, X-Forwarded-For is only
+	// believed from the proxies listed in TRUSTED_PROXIES, without them it is ignored
+	if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES: ", err)
+	}
+
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"http://localhost:3000"}
 	config.AllowCredentials = true
@@ -22,38 +33,105 @@ func NewRouter() *gin.Engine {
 	v1 := r.Group("/api/v1")
 	v1.GET("/", handlers.Methods.GetStatus)
 
-	v1.POST("/auth/login", handlers.Methods.LoginHandler)
-	v1.POST("/auth/signup", handlers.Methods.SignupHandler)
-	v1.POST("/auth/request_forgot_password", handlers.Methods.RequestForgotPassword)
+	v1.POST("/auth/login", RateLimit("login"), handlers.Methods.LoginHandler)
+	v1.POST("/auth/2fa/verify", RateLimit("login"), handlers.Methods.HandleVerifyTwoFactor)
+	v1.POST("/auth/signup", RateLimit("signup"), handlers.Methods.SignupHandler)
+	v1.POST("/auth/request_forgot_password", RateLimit("forgot_password"), handlers.Methods.RequestForgotPassword)
+	v1.POST("/auth/verify_email", handlers.Methods.HandleVerifyEmailChange)
+	v1.GET("/auth/oidc", handlers.Methods.HandleGetOIDCProviders)
+	v1.GET("/auth/oidc/:provider/start", RateLimit("login"), handlers.Methods.HandleStartOIDCLogin)
+	v1.POST("/auth/oidc/:provider/callback", RateLimit("login"), handlers.Methods.HandleOIDCCallback)
+
+	v1.GET("/me", IsLoggedIn, handlers.Methods.HandleMyAuthInfo)
+	v1.PATCH("/me", IsLoggedIn, handlers.Methods.HandleUpdateMe)
+	v1.PUT("/me/password", IsLoggedIn, handlers.Methods.HandleChangePassword)
+	v1.POST("/me/export", IsLoggedIn, handlers.Methods.HandleRequestDataExport)
+	v1.GET("/me/exports", IsLoggedIn, handlers.Methods.HandleGetDataExports)
+	v1.GET("/me/exports/:exportID/download", NoAPIKey, IsLoggedIn, handlers.Methods.HandleDownloadDataExport)
+	v1.POST("/me/delete", IsLoggedIn, handlers.Methods.HandleDeleteAccount)
+	v1.DELETE("/me/delete", IsLoggedIn, handlers.Methods.HandleCancelAccountDeletion)
+	v1.GET("/me/2fa", IsLoggedIn, handlers.Methods.HandleGetTwoFactor)
+	v1.POST("/me/2fa/setup", HasToken, handlers.Methods.HandleSetupTwoFactor)
+	v1.POST("/me/2fa/enable", HasToken, handlers.Methods.HandleEnableTwoFactor)
+	v1.POST("/me/2fa/disable", IsLoggedIn, handlers.Methods.HandleDisableTwoFactor)
+	v1.POST("/me/2fa/recovery_codes", IsLoggedIn, handlers.Methods.HandleRegenerateRecoveryCodes)
+	v1.GET("/me/api_keys", IsLoggedIn, handlers.Methods.HandleGetAPIKeys)
+	v1.POST("/me/api_keys", IsLoggedIn, handlers.Methods.HandleCreateAPIKey)
+	v1.DELETE("/me/api_keys/:keyID", IsLoggedIn, handlers.Methods.HandleDeleteAPIKey)
 
 	v1.GET("/videos", handlers.Methods.HandleGetAllVideos)
-	v1.POST("/videos", isAuthor, handlers.Methods.HandleCreateVideo)
-	v1.POST("/videos/:videoID", isAuthor, handlers.Methods.HandleUpdateVideo)
-	v1.GET("/get_videos/:channelID", handlers.Methods.HandleGetVideosByChannelID)
+	v1.POST("/videos", Scope(models.ScopeUpload), IsLoggedIn, handlers.Methods.HandleCreateVideo)
+	v1.POST("/videos/bulk", Scope(models.ScopeUpload), IsLoggedIn, handlers.Methods.HandleBulkUpdateVideos)
+	v1.POST("/videos/:videoID", Scope(models.ScopeUpload), IsLoggedIn, handlers.Methods.HandleUpdateVideo)
+	v1.GET("/get_videos/:channelID", handlers.Methods.ResolveChannelHandle, handlers.Methods.HandleGetVideosByChannelID)
 	v1.GET("/videos/:videoID", HasToken, handlers.Methods.HandleGetSingleVideo)
-	v1.DELETE("/videos/:videoID", isAuthor, handlers.Methods.HandleDeleteVideo)
-	v1.GET("/related_videos/:channelID", handlers.Methods.HandleGetRelatedVideos)
+	v1.GET("/videos/:videoID/status", IsLoggedIn, handlers.Methods.HandleGetVideoStatus)
+	v1.POST("/videos/:videoID/watch_time", HasToken, RateLimitPerVideo("watch_time"), handlers.Methods.HandleRecordWatchTime)
+	v1.GET("/videos/:videoID/thumbnails", IsLoggedIn, handlers.Methods.HandleGetVideoThumbnails)
+	v1.GET("/videos/:videoID/storyboard.vtt", HasToken, handlers.Methods.HandleGetVideoStoryboard)
+	v1.GET("/videos/:videoID/captions", HasToken, handlers.Methods.HandleGetCaptions)
+	v1.GET("/videos/:videoID/captions/:language", HasToken, handlers.Methods.HandleGetCaption)
+	v1.POST("/videos/:videoID/captions", Scope(models.ScopeUpload), IsLoggedIn, handlers.Methods.HandleUploadCaption)
+	v1.DELETE("/videos/:videoID/captions/:language", Scope(models.ScopeUpload), IsLoggedIn, handlers.Methods.HandleDeleteCaption)
+	v1.PATCH("/videos/:videoID/thumbnails/:thumbnailID", Scope(models.ScopeUpload), IsLoggedIn, handlers.Methods.HandleSelectVideoThumbnail)
+	v1.DELETE("/videos/:videoID", Scope(models.ScopeUpload), IsLoggedIn, handlers.Methods.HandleDeleteVideo)
+	v1.POST("/videos/:videoID/restore", Scope(models.ScopeUpload), IsLoggedIn, handlers.Methods.HandleRestoreVideo)
+	v1.GET("/related_videos/:videoID", HasToken, handlers.Methods.HandleGetRelatedVideos)
 	v1.GET("/file/video/:videoID", handlers.Methods.StreamVideoBuff)
 
-	v1.GET("/subscribed_channels/:channelID", IsLoggedIn, handlers.Methods.HandleGetSubscribedChannels)
+	v1.GET("/subscribed_channels/:channelID", NoAPIKey, handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleToggleSubscription)
+	v1.GET("/me/subscriptions", IsLoggedIn, handlers.Methods.HandleGetSubscribedChannels)
+	v1.GET("/feed/subscriptions", IsLoggedIn, handlers.Methods.HandleGetSubscriptionFeed)
+	v1.GET("/feed/home", HasToken, handlers.Methods.HandleGetHomeFeed)
+	v1.GET("/trending", handlers.Methods.HandleGetTrendingVideos)
+	v1.GET("/categories", handlers.Methods.HandleGetCategories)
+	v1.GET("/categories/:category/videos", handlers.Methods.HandleGetVideosByCategory)
+	v1.GET("/tags/:tag/videos", handlers.Methods.HandleGetVideosByTag)
 	v1.GET("/notifications", IsLoggedIn, handlers.Methods.HandleGetNotifications)
 	v1.PATCH("/notifications/:notificationID", IsLoggedIn, handlers.Methods.HandleUpdateNotification)
 
 	v1.POST("/comments", IsLoggedIn, handlers.Methods.HandleCreateOrUpdateComment)
-	v1.DELETE("/comments/:commentID", IsLoggedIn, handlers.Methods.HandleDeleteComment)
+	v1.DELETE("/comments/:commentID", Scope(models.ScopeModerate), IsLoggedIn, handlers.Methods.HandleDeleteComment)
 	v1.GET("/comments/:videoID", handlers.Methods.HandleGetComments)
 
-	v1.GET("/likes/:videoID", IsLoggedIn, handlers.Methods.HandleVideoLike)
+	v1.GET("/likes/:videoID", NoAPIKey, IsLoggedIn, handlers.Methods.HandleVideoLike)
 	v1.GET("/liked_videos", IsLoggedIn, handlers.Methods.HandleGetLikedVideos)
 
-	v1.GET("/channels", isAuthor, handlers.Methods.HandleGetChannels)
-	v1.GET("/channels_by_user_with_details", isAuthor, handlers.Methods.HandleGetChannelsWithDetailsByUserID)
-	v1.POST("/channels", isAuthor, handlers.Methods.HandleCreateChannel)
-	v1.PATCH("/channels/:channelID", isAuthor, handlers.Methods.HandleEditChannel)
-	v1.GET("/channels/:channelID", handlers.Methods.HandleGetChannel)
-	v1.DELETE("/channels/:channelID", isAuthor, handlers.Methods.HandleDeleteChannel)
-	v1.GET("/get_channel_videos/:channelID", handlers.Methods.HandleGetChannelsVideos)
-	v1.GET("/get_channel_with_details/:channelID", HasToken, handlers.Methods.HandleGetChannelWithDetails)
+	v1.GET("/channels", IsLoggedIn, handlers.Methods.HandleGetChannels)
+	v1.GET("/channels_by_user_with_details", IsLoggedIn, handlers.Methods.HandleGetChannelsWithDetailsByUserID)
+	v1.POST("/channels", Scope(models.ScopeManageChannel), isAuthor, handlers.Methods.HandleCreateChannel)
+	v1.PATCH("/channels/:channelID", Scope(models.ScopeManageChannel), handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleEditChannel)
+	v1.GET("/channels/:channelID", handlers.Methods.ResolveChannelHandle, handlers.Methods.HandleGetChannel)
+	v1.DELETE("/channels/:channelID", Scope(models.ScopeManageChannel), handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleDeleteChannel)
+	v1.POST("/channels/:channelID/restore", Scope(models.ScopeManageChannel), IsLoggedIn, handlers.Methods.HandleRestoreChannel)
+	v1.GET("/trash", IsLoggedIn, handlers.Methods.HandleGetTrash)
+	v1.GET("/get_channel_videos/:channelID", handlers.Methods.ResolveChannelHandle, handlers.Methods.HandleGetChannelsVideos)
+	v1.GET("/get_channel_with_details/:channelID", handlers.Methods.ResolveChannelHandle, HasToken, handlers.Methods.HandleGetChannelWithDetails)
+	v1.PATCH("/channels/:channelID/handle", Scope(models.ScopeManageChannel), handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleUpdateChannelHandle)
+	v1.GET("/handles/:handle", handlers.Methods.HandleCheckHandle)
+	v1.PUT("/channels/:channelID/cover", Scope(models.ScopeManageChannel), handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleUpdateChannelCover)
+	v1.DELETE("/channels/:channelID/cover", Scope(models.ScopeManageChannel), handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleDeleteChannelCover)
+	v1.PUT("/channels/:channelID/watermark", Scope(models.ScopeManageChannel), handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleUpdateChannelWatermark)
+	v1.DELETE("/channels/:channelID/watermark", Scope(models.ScopeManageChannel), handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleDeleteChannelWatermark)
+	v1.GET("/channels/:channelID/videos", handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleGetManagedChannelVideos)
+	v1.GET("/channels/:channelID/analytics", handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleGetChannelAnalytics)
+	v1.GET("/channels/:channelID/export/videos", handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleExportChannelVideos)
+	v1.GET("/channels/:channelID/export/subscribers", handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleExportChannelSubscribers)
+	v1.GET("/channels/:channelID/export/comments", handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleExportChannelComments)
+	v1.GET("/channels/:channelID/members", handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleGetChannelMembers)
+	v1.PATCH("/channels/:channelID/members/:userID", Scope(models.ScopeManageChannel), handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleUpdateChannelMember)
+	v1.DELETE("/channels/:channelID/members/:userID", Scope(models.ScopeManageChannel), handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleDeleteChannelMember)
+	v1.GET("/channels/:channelID/invites", handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleGetChannelInvites)
+	v1.POST("/channels/:channelID/invites", Scope(models.ScopeManageChannel), handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleInviteChannelMember)
+	v1.DELETE("/channels/:channelID/invites/:inviteID", Scope(models.ScopeManageChannel), handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleDeleteChannelInvite)
+	v1.GET("/channels/:channelID/webhooks", handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleGetWebhooks)
+	v1.POST("/channels/:channelID/webhooks", Scope(models.ScopeManageChannel), handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleCreateWebhook)
+	v1.PATCH("/channels/:channelID/webhooks/:webhookID", Scope(models.ScopeManageChannel), handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleUpdateWebhook)
+	v1.DELETE("/channels/:channelID/webhooks/:webhookID", Scope(models.ScopeManageChannel), handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleDeleteWebhook)
+	v1.GET("/channels/:channelID/webhooks/:webhookID/deliveries", handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleGetWebhookDeliveries)
+	v1.POST("/channels/:channelID/webhooks/:webhookID/test", Scope(models.ScopeManageChannel), handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleTestWebhook)
+	v1.POST("/channels/:channelID/transfer", handlers.Methods.ResolveChannelHandle, IsLoggedIn, handlers.Methods.HandleTransferChannel)
+	v1.POST("/channel_invites/:token/accept", IsLoggedIn, handlers.Methods.HandleAcceptChannelInvite)
 
 	v1.POST("/contact_us", HasToken, handlers.Methods.HandleContactUs)
 
@@ -62,3 +140,15 @@ func NewRouter() *gin.Engine {
 
 	return r
 }
+
+// read the comma separated IPs or CIDRs of the reverse proxies in front of the app
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
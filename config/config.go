@@ -3,11 +3,16 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/gorilla/websocket"
 	"github.com/raihan2bd/vidverse/initializers"
 	"github.com/raihan2bd/vidverse/internal/mail"
+	"github.com/raihan2bd/vidverse/internal/oidc"
+	"github.com/raihan2bd/vidverse/internal/ratelimit"
+	"github.com/raihan2bd/vidverse/internal/recommend"
 	"github.com/raihan2bd/vidverse/repository"
 	dbrepo "github.com/raihan2bd/vidverse/repository/dbRepo"
 	"gorm.io/gorm"
@@ -19,6 +24,14 @@ type Application struct {
 	DBMethods        repository.DatabaseRepo
 	NotificationChan chan *NotificationEvent
 	Mailer           mail.Mail
+	Recommender      *recommend.Engine
+	VideoJobChan     chan *VideoJob
+	TrashRetention   time.Duration
+	DeletionGrace    time.Duration
+	RateLimiters     map[string]*ratelimit.Limiter
+	AccountThrottle  *ratelimit.Backoff
+	IPThrottle       *ratelimit.Backoff
+	OIDC             *oidc.Registry
 }
 
 type NotificationEvent struct {
@@ -28,6 +41,16 @@ type NotificationEvent struct {
 	Conn          *websocket.Conn
 }
 
+// VideoJob is an uploaded video waiting to be processed in the background
+type VideoJob struct {
+	VideoID   uint
+	UserID    uint
+	VideoPath string
+	ThumbPath string
+	// the job replaces the file of a video the subscribers were already told about
+	WasPublished bool
+}
+
 func LoadConfig() (*Application, error) {
 	var (
 		cld *cloudinary.Cloudinary
@@ -62,11 +85,84 @@ func LoadConfig() (*Application, error) {
 		FromAddress: os.Getenv("MAIL_FROM_ADDRESS"),
 	}
 
+	// deleted channels and videos can be restored for this many days
+	retentionDays := EnvInt("TRASH_RETENTION_DAYS", 30)
+
+	// a deleted account can be recovered for this many days
+	graceDays := EnvInt("ACCOUNT_DELETION_GRACE_DAYS", 14)
+
+	// the failed logins of an account slow down and then lock it, the same for an IP with higher limits,
+	// the throttles and rate limits live in memory so they reset on a restart and are per instance
+	lockFor := time.Duration(EnvInt("LOGIN_LOCKOUT_MINUTES", 15)) * time.Minute
+	backoffBase := time.Duration(EnvInt("LOGIN_BACKOFF_SECONDS", 1)) * time.Second
+	backoffMax := time.Duration(EnvInt("LOGIN_BACKOFF_MAX_SECONDS", 300)) * time.Second
+	accountThrottle := ratelimit.NewBackoff(EnvInt("LOGIN_FREE_ATTEMPTS", 3), backoffBase, backoffMax, EnvInt("LOGIN_LOCKOUT_ATTEMPTS", 10), lockFor)
+	ipThrottle := ratelimit.NewBackoff(EnvInt("LOGIN_IP_FREE_ATTEMPTS", 10), backoffBase, backoffMax, EnvInt("LOGIN_IP_LOCKOUT_ATTEMPTS", 50), lockFor)
+
+	rateLimiters := map[string]*ratelimit.Limiter{
+		"login":                 ratelimit.New(EnvInt("RATE_LIMIT_LOGIN_PER_MINUTE", 10), time.Minute),
+		"signup":                ratelimit.New(EnvInt("RATE_LIMIT_SIGNUP_PER_HOUR", 5), time.Hour),
+		"forgot_password":       ratelimit.New(EnvInt("RATE_LIMIT_FORGOT_PASSWORD_PER_HOUR", 5), time.Hour),
+		"forgot_password_email": ratelimit.New(EnvInt("RATE_LIMIT_FORGOT_PASSWORD_EMAIL_PER_HOUR", 3), time.Hour),
+		"watch_time":            ratelimit.New(EnvInt("RATE_LIMIT_WATCH_TIME_PER_MINUTE", 6), time.Minute),
+	}
+
 	return &Application{
 		DB:               db,
 		DBMethods:        dbrepo.NewPostgresRepo(initializers.DB, initializers.CLD),
 		CLD:              cld,
 		NotificationChan: make(chan *NotificationEvent),
 		Mailer:           m,
+		Recommender:      recommend.New(15 * time.Minute),
+		VideoJobChan:     make(chan *VideoJob, 100),
+		TrashRetention:   time.Duration(retentionDays) * 24 * time.Hour,
+		DeletionGrace:    time.Duration(graceDays) * 24 * time.Hour,
+		RateLimiters:     rateLimiters,
+		AccountThrottle:  accountThrottle,
+		IPThrottle:       ipThrottle,
+		OIDC:             oidc.NewRegistry(oidcConfigs()),
 	}, nil
 }
+
+// read the OpenID Connect providers from the environment, OIDC_PROVIDERS lists their names
+// and OIDC_<NAME>_DISCOVERY_URL, OIDC_<NAME>_CLIENT_ID and OIDC_<NAME>_CLIENT_SECRET configure each one
+func oidcConfigs() []oidc.Config {
+	var configs []oidc.Config
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		config := oidc.Config{
+			Name:         name,
+			DiscoveryURL: os.Getenv(prefix + "DISCOVERY_URL"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if config.DiscoveryURL == "" || config.ClientID == "" {
+			continue
+		}
+
+		// the frontend gets the callback and passes the code to the API
+		if config.RedirectURL == "" {
+			config.RedirectURL = os.Getenv("APP_DOMAIN") + "/auth/oidc/" + name + "/callback"
+		}
+
+		configs = append(configs, config)
+	}
+
+	return configs
+}
+
+// EnvInt read a positive number from the environment or fallback to the default value
+func EnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
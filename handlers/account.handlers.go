@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihan2bd/vidverse/internal/mail"
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// HandleRequestDataExport queue a ZIP of the data of the logged in user, it is built in the background
+func (m *Repo) HandleRequestDataExport(c *gin.Context) {
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	userID := uint(user_id.(float64))
+
+	exports, err := m.App.DBMethods.GetDataExports(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, export := range exports {
+		if export.Status == models.ExportPending || export.Status == models.ExportProcessing {
+			c.JSON(http.StatusConflict, gin.H{"error": "Your data export is already being prepared", "export": export})
+			return
+		}
+	}
+
+	export := models.DataExport{UserID: userID, Status: models.ExportPending}
+	err = m.App.DBMethods.CreateDataExport(&export)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Your data export is being prepared. It can be downloaded once it is ready", "export": export})
+}
+
+// HandleGetDataExports list the data exports of the logged in user
+func (m *Repo) HandleGetDataExports(c *gin.Context) {
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	exports, err := m.App.DBMethods.GetDataExports(uint(user_id.(float64)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"exports": exports})
+}
+
+// HandleDownloadDataExport send the ZIP of a ready data export of the logged in user
+func (m *Repo) HandleDownloadDataExport(c *gin.Context) {
+	exportID, err := strconv.Atoi(c.Param("exportID"))
+	if err != nil || exportID <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 data export not found"})
+		return
+	}
+
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	export, err := m.App.DBMethods.GetDataExport(uint(user_id.(float64)), uint(exportID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if export.Status != models.ExportReady || export.ExpiresAt == nil || export.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This data export is not ready or has expired"})
+		return
+	}
+
+	c.FileAttachment(export.FilePath, fmt.Sprintf("vidverse-data-%s.zip", export.CreatedAt.Format("2006-01-02")))
+}
+
+// HandleDeleteAccount schedule the deletion of the logged in user, the account can be recovered during the grace period
+func (m *Repo) HandleDeleteAccount(c *gin.Context) {
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, err := m.App.DBMethods.GetUserByID(uint(user_id.(float64)))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var payload struct {
+		Password    string `json:"password"`
+		ReauthToken string `json:"reauth_token"`
+	}
+
+	err = c.BindJSON(&payload)
+	if err != nil || (payload.Password == "" && payload.ReauthToken == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "password or reauth_token is required"})
+		return
+	}
+
+	if !m.confirmIdentity(user, payload.Password, payload.ReauthToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "The password is not correct"})
+		return
+	}
+
+	if user.DeleteAfter != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Your account is already scheduled for deletion", "delete_after": user.DeleteAfter})
+		return
+	}
+
+	deleteAfter := time.Now().Add(m.App.DeletionGrace)
+	err = m.App.DBMethods.ScheduleAccountDeletion(user.ID, &deleteAfter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = m.App.Mailer.SendSmtpMessage(mail.Message{
+		From:    m.App.Mailer.FromAddress,
+		To:      user.Email,
+		Subject: "Your account is scheduled for deletion",
+		DataMap: map[string]any{
+			"message": fmt.Sprintf("Dear %s, your account and your channels will be deleted for good on %s. Your comments will stay without your name. If you change your mind, log in and cancel the deletion before then.", user.Name, deleteAfter.Format("January 2, 2006")),
+		},
+	})
+	if err != nil {
+		log.Println(err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Your account is scheduled for deletion", "delete_after": deleteAfter})
+}
+
+// HandleCancelAccountDeletion keep the account of the logged in user during the grace period
+func (m *Repo) HandleCancelAccountDeletion(c *gin.Context) {
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, err := m.App.DBMethods.GetUserByID(uint(user_id.(float64)))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if user.DeleteAfter == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Your account is not scheduled for deletion"})
+		return
+	}
+
+	err = m.App.DBMethods.ScheduleAccountDeletion(user.ID, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "The deletion of your account has been cancelled"})
+}
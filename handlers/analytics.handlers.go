@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// the longest date range the analytics can be requested for
+const maxAnalyticsDays = 365
+
+// save an analytics event, a failure must never break the request that caused it
+func (m *Repo) recordEvent(eventType string, channelID, videoID, userID uint) {
+	err := m.App.DBMethods.RecordAnalyticsEvent(&models.AnalyticsEvent{Type: eventType, ChannelID: channelID, VideoID: videoID, UserID: userID})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// HandleGetChannelAnalytics get the daily stats and the top videos of a channel in a date range
+func (m *Repo) HandleGetChannelAnalytics(c *gin.Context) {
+	channel, ok := m.findManagedChannel(c, models.PermViewAnalytics)
+	if !ok {
+		return
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	to, err := time.Parse("2006-01-02", c.DefaultQuery("to", today.Format("2006-01-02")))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date. Please use the YYYY-MM-DD format"})
+		return
+	}
+
+	from, err := time.Parse("2006-01-02", c.DefaultQuery("from", to.AddDate(0, 0, -27).Format("2006-01-02")))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date. Please use the YYYY-MM-DD format"})
+		return
+	}
+
+	if from.After(to) || to.Sub(from) >= maxAnalyticsDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date range. The range can be at most 365 days"})
+		return
+	}
+
+	var videoID int
+	if raw := c.Query("video_id"); raw != "" {
+		videoID, err = strconv.Atoi(raw)
+		if err != nil || videoID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid video id"})
+			return
+		}
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit number"})
+		return
+	}
+
+	stats, err := m.App.DBMethods.GetChannelDailyStats(channel.ID, uint(videoID), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	topVideos, err := m.App.DBMethods.GetChannelTopVideos(channel.ID, from, to, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	// fill the days without any activity so the chart has a point for every day
+	byDay := make(map[string]models.DailyStatDTO, len(stats))
+	for _, stat := range stats {
+		byDay[stat.Day.Format("2006-01-02")] = stat
+	}
+
+	var totals models.DailyStatDTO
+	daily := make([]models.DailyStatDTO, 0, int(to.Sub(from).Hours()/24)+1)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		stat := byDay[day.Format("2006-01-02")]
+		stat.Day = day
+		daily = append(daily, stat)
+
+		totals.Views += stat.Views
+		totals.Likes += stat.Likes
+		totals.Comments += stat.Comments
+		totals.SubscribersGained += stat.SubscribersGained
+		totals.SubscribersLost += stat.SubscribersLost
+		totals.WatchSeconds += stat.WatchSeconds
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"channel_id": channel.ID,
+		"from":       from.Format("2006-01-02"),
+		"to":         to.Format("2006-01-02"),
+		"totals": gin.H{
+			"views":              totals.Views,
+			"likes":              totals.Likes,
+			"comments":           totals.Comments,
+			"subscribers_gained": totals.SubscribersGained,
+			"subscribers_lost":   totals.SubscribersLost,
+			"watch_seconds":      totals.WatchSeconds,
+		},
+		"daily":      daily,
+		"top_videos": topVideos,
+	})
+}
+
+// HandleRecordWatchTime record how long the video was watched since the last report of the player
+func (m *Repo) HandleRecordWatchTime(c *gin.Context) {
+	// only the watch time of a video the viewer can see is counted
+	video, ok := m.findViewableVideo(c)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Seconds float64 `json:"seconds"`
+	}
+
+	err := c.BindJSON(&payload)
+	if err != nil || payload.Seconds <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watch time"})
+		return
+	}
+
+	// a single report can not be longer than the video itself
+	maxSeconds := video.Duration
+	if maxSeconds <= 0 {
+		maxSeconds = 3600
+	}
+	if payload.Seconds > maxSeconds {
+		payload.Seconds = maxSeconds
+	}
+
+	var userID uint
+	if id, ok := c.Get("user_id"); ok {
+		userID = uint(id.(float64))
+	}
+
+	err = m.App.DBMethods.RecordAnalyticsEvent(&models.AnalyticsEvent{Type: models.EventWatch, ChannelID: video.ChannelID, VideoID: video.ID, UserID: userID, WatchSeconds: payload.Seconds})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "watch time recorded"})
+}
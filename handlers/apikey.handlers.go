@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihan2bd/vidverse/helpers"
+	"github.com/raihan2bd/vidverse/models"
+	validator "github.com/raihan2bd/vidverse/validators"
+)
+
+// how many API keys a user can have
+const maxAPIKeys = 20
+
+// HandleGetAPIKeys list the API keys of the logged in user, the keys themselves are never shown again
+func (m *Repo) HandleGetAPIKeys(c *gin.Context) {
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	keys, err := m.App.DBMethods.GetAPIKeys(uint(user_id.(float64)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys, "scopes": models.APIKeyScopes})
+}
+
+// HandleCreateAPIKey create a named API key with scopes, the key is only shown in this response
+func (m *Repo) HandleCreateAPIKey(c *gin.Context) {
+	// an API key can not create more keys
+	if _, ok := c.Get("api_key_id"); ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys can only be created when logged in"})
+		return
+	}
+
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	userID := uint(user_id.(float64))
+
+	var payload struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+
+	err := c.BindJSON(&payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload inputs"})
+		return
+	}
+
+	payload.Name = strings.TrimSpace(payload.Name)
+
+	v := validator.New()
+	v.IsLength(payload.Name, "name", 1, 100)
+	v.Check(len(payload.Scopes) > 0, "scopes", "At least one scope is required")
+	for _, scope := range payload.Scopes {
+		v.IsIn(scope, "scopes", models.APIKeyScopes, "Scopes must be in "+strings.Join(models.APIKeyScopes, ", "))
+	}
+	v.Check(payload.ExpiresInDays >= 0 && payload.ExpiresInDays <= 365, "expires_in_days", "The key must expire in 365 days or less, 0 for never")
+	if !v.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": v.GetErrMsg()})
+		return
+	}
+
+	keys, err := m.App.DBMethods.GetAPIKeys(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(keys) >= maxAPIKeys {
+		c.JSON(http.StatusConflict, gin.H{"error": "You can have up to 20 API keys. Please revoke one first"})
+		return
+	}
+
+	token, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create the API key"})
+		return
+	}
+	plainKey := models.APIKeyPrefix + strings.TrimRight(token, "=")
+
+	// the scopes are saved once each in a fixed order
+	var scopes []string
+	for _, scope := range models.APIKeyScopes {
+		for _, requested := range payload.Scopes {
+			if requested == scope {
+				scopes = append(scopes, scope)
+				break
+			}
+		}
+	}
+
+	key := models.APIKey{
+		UserID:  userID,
+		Name:    payload.Name,
+		Prefix:  plainKey[:len(models.APIKeyPrefix)+8],
+		KeyHash: helpers.HashToken(plainKey),
+		Scopes:  strings.Join(scopes, ","),
+	}
+	if payload.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, payload.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+
+	err = m.App.DBMethods.CreateAPIKey(&key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Please copy the key now, it will not be shown again",
+		"key":     plainKey,
+		"api_key": key,
+	})
+}
+
+// HandleDeleteAPIKey revoke an API key of the logged in user
+func (m *Repo) HandleDeleteAPIKey(c *gin.Context) {
+	keyID, err := strconv.Atoi(c.Param("keyID"))
+	if err != nil || keyID <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 API key not found"})
+		return
+	}
+
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	err = m.App.DBMethods.DeleteAPIKey(uint(user_id.(float64)), uint(keyID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "The API key has been revoked"})
+}
@@ -6,10 +6,8 @@ import (
 	"net/http"
 	"os"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/raihan2bd/vidverse/helpers"
 	"github.com/raihan2bd/vidverse/internal/mail"
 	"github.com/raihan2bd/vidverse/models"
@@ -32,9 +30,15 @@ func (m *Repo) LoginHandler(c *gin.Context) {
 		return
 	}
 
+	if wait := m.loginWait(c, payload.Email); wait > 0 {
+		tooManyAttempts(c, wait)
+		return
+	}
+
 	user, _ := m.App.DBMethods.GetUserByEmail(payload.Email)
 
 	if user == nil {
+		m.loginFailed(c, payload.Email, nil)
 		c.IndentedJSON(http.StatusUnauthorized, gin.H{
 			"error": "Invalid email or password",
 		})
@@ -51,26 +55,26 @@ func (m *Repo) LoginHandler(c *gin.Context) {
 	// Compare the password
 	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(payload.Password))
 	if err != nil {
+		m.loginFailed(c, payload.Email, user)
 		c.IndentedJSON(http.StatusUnauthorized, gin.H{
 			"error": "Invalid email or password",
 		})
 		return
 	}
 
-	exp := time.Now().Add(time.Hour * 24 * 7).Unix()
-
-	// Generate Token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":       user.ID,
-		"user_role": user.UserRole,
-		"user_name": user.Name,
-		"exp":       exp,
-	})
-
-	jwtSecret := os.Getenv("JWT_SECRET")
+	// users with two-factor authentication and admins need a second step
+	if user.TOTPEnabled || user.UserRole == "admin" {
+		m.sendTwoFactorChallenge(c, user, false)
+		return
+	}
 
-	tokenString, err := token.SignedString([]byte(jwtSecret))
+	m.loginSucceeded(payload.Email)
+	m.sendLoginToken(c, user, false, nil)
+}
 
+// send the token of a logged in user, extra fields are added to the response
+func (m *Repo) sendLoginToken(c *gin.Context, user *models.User, mfa bool, extra gin.H) {
+	tokenString, exp, err := helpers.CreateLoginToken(user, mfa)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"message": "Failed to create token",
@@ -91,16 +95,40 @@ func (m *Repo) LoginHandler(c *gin.Context) {
 	userResponse.Avatar = user.Avatar
 	userResponse.Username = user.Name
 
-	// send it as a response
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"user":       userResponse,
 		"token":      tokenString,
 		"expires_at": exp,
-	})
+	}
+	for key, value := range extra {
+		response[key] = value
+	}
 
+	// send it as a response
+	c.JSON(http.StatusOK, response)
 }
 
+// HandleMyAuthInfo get the profile of the logged in user
 func (m *Repo) HandleMyAuthInfo(c *gin.Context) {
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, err := m.App.DBMethods.GetUserByID(uint(user_id.(float64)))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// the new email is shown until it is verified
+	var pendingEmail string
+	if change, err := m.App.DBMethods.GetPendingEmailChange(user.ID); err == nil {
+		pendingEmail = change.NewEmail
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user, "pending_email": pendingEmail})
 }
 
 func (m *Repo) SignupHandler(c *gin.Context) {
@@ -212,6 +240,14 @@ func (m *Repo) RequestForgotPassword(c *gin.Context) {
 		return
 	}
 
+	// the same address can not be flooded with reset emails
+	if ok, _ := m.App.RateLimiters["forgot_password_email"].Allow(strings.ToLower(payload.Email)); !ok {
+		c.IndentedJSON(http.StatusTooManyRequests, gin.H{
+			"error": "Too many reset requests for this email. Please try again later",
+		})
+		return
+	}
+
 	user, err := m.App.DBMethods.GetUserByEmail(payload.Email)
 	if err != nil {
 		c.IndentedJSON(500, gin.H{
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihan2bd/vidverse/helpers"
+	"github.com/raihan2bd/vidverse/models"
+	validator "github.com/raihan2bd/vidverse/validators"
+)
+
+// size rules of the channel branding images
+const (
+	coverMinWidth    = 2048
+	coverMinHeight   = 1152
+	coverMaxSize     = 5 * 1024 * 1024
+	watermarkMinSize = 150
+	watermarkMaxSize = 1024 * 1024
+)
+
+const (
+	channelCoverFolder     = "vidverse/uploads/channel_covers"
+	channelWatermarkFolder = "vidverse/uploads/channel_watermarks"
+)
+
+// validate the type, size and dimensions of an uploaded cover
+func validateCover(file multipart.File, header *multipart.FileHeader, v *validator.Validator) {
+	validateChannelImage(file, header, "cover", v, coverMaxSize, coverMinWidth, coverMinHeight, 16, 9)
+}
+
+// validate the type, size and dimensions of an uploaded watermark
+func validateWatermark(file multipart.File, header *multipart.FileHeader, v *validator.Validator) {
+	validateChannelImage(file, header, "watermark", v, watermarkMaxSize, watermarkMinSize, watermarkMinSize, 1, 1)
+}
+
+func validateChannelImage(file multipart.File, header *multipart.FileHeader, key string, v *validator.Validator, maxSize int64, minWidth, minHeight, ratioWidth, ratioHeight int) {
+	v.IsImage(header.Header.Get("Content-Type"), key)
+	v.Check(header.Size <= maxSize, key, fmt.Sprintf("Invalid image size. Maximum size of the %s is %dKB", key, maxSize/1024))
+	if !v.Valid() {
+		return
+	}
+
+	width, height, err := helpers.ImageDimensions(file)
+	if err != nil {
+		v.AddError(key, "Invalid image. Please upload a valid png or jpeg image")
+		return
+	}
+
+	v.IsImageDimensions(width, height, key, minWidth, minHeight, ratioWidth, ratioHeight)
+}
+
+// save the new branding of a channel, the new file is removed when it fails and the replaced file when it succeeds
+func (m *Repo) saveChannelBranding(c *gin.Context, channel *models.CustomChannelDTO, newPublicID, oldPublicID, message string) {
+	ctx := context.Background()
+
+	err := m.App.DBMethods.UpdateChannel(channel)
+	if err != nil {
+		if newPublicID != "" {
+			_ = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, newPublicID)
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update channel"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message, "channel": channel})
+
+	if oldPublicID != "" && oldPublicID != newPublicID {
+		err = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, oldPublicID)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// HandleUpdateChannelCover upload or replace the cover of a channel
+func (m *Repo) HandleUpdateChannelCover(c *gin.Context) {
+	channel, ok := m.findManagedChannel(c, models.PermManageChannel)
+	if !ok {
+		return
+	}
+
+	file, header, err := c.Request.FormFile("cover")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cover is required."})
+		return
+	}
+	defer file.Close()
+
+	v := validator.New()
+	validateCover(file, header, v)
+	if !v.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": v.GetErrMsg()})
+		return
+	}
+
+	secureURL, publicID, err := helpers.UploadImageToCloudinary(context.Background(), m.App.CLD, file, channelCoverFolder)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload cover"})
+		return
+	}
+
+	oldPublicID := channel.CoverPublicID
+	channel.Cover, channel.CoverPublicID = secureURL, publicID
+
+	m.saveChannelBranding(c, channel, publicID, oldPublicID, "Cover updated successfully!")
+}
+
+// HandleDeleteChannelCover remove the cover of a channel and fallback to the default cover
+func (m *Repo) HandleDeleteChannelCover(c *gin.Context) {
+	channel, ok := m.findManagedChannel(c, models.PermManageChannel)
+	if !ok {
+		return
+	}
+
+	oldPublicID := channel.CoverPublicID
+	channel.Cover, channel.CoverPublicID = models.DefaultChannelCover, ""
+
+	m.saveChannelBranding(c, channel, "", oldPublicID, "Cover removed successfully!")
+}
+
+// HandleUpdateChannelWatermark upload or replace the watermark shown on the player and change its position
+func (m *Repo) HandleUpdateChannelWatermark(c *gin.Context) {
+	channel, ok := m.findManagedChannel(c, models.PermManageChannel)
+	if !ok {
+		return
+	}
+
+	file, header, _ := c.Request.FormFile("watermark")
+	if file != nil {
+		defer file.Close()
+	}
+
+	position := c.PostForm("position")
+
+	v := validator.New()
+	if file == nil && position == "" {
+		v.AddError("watermark", "Watermark or position is required.")
+	}
+	if file != nil && header != nil {
+		validateWatermark(file, header, v)
+	}
+	if position != "" {
+		v.IsIn(position, "position", models.WatermarkPositions, "Invalid position. Position must be top-left, top-right, bottom-left or bottom-right")
+	}
+	if file == nil && channel.Watermark == "" {
+		v.AddError("watermark", "Watermark is required.")
+	}
+
+	if !v.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": v.GetErrMsg()})
+		return
+	}
+
+	var publicID, oldPublicID string
+	if file != nil {
+		var (
+			secureURL string
+			err       error
+		)
+		secureURL, publicID, err = helpers.UploadImageToCloudinary(context.Background(), m.App.CLD, file, channelWatermarkFolder)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload watermark"})
+			return
+		}
+
+		oldPublicID = channel.WatermarkPublicID
+		channel.Watermark, channel.WatermarkPublicID = secureURL, publicID
+	}
+
+	if position != "" {
+		channel.WatermarkPosition = position
+	}
+
+	m.saveChannelBranding(c, channel, publicID, oldPublicID, "Watermark updated successfully!")
+}
+
+// HandleDeleteChannelWatermark remove the watermark of a channel
+func (m *Repo) HandleDeleteChannelWatermark(c *gin.Context) {
+	channel, ok := m.findManagedChannel(c, models.PermManageChannel)
+	if !ok {
+		return
+	}
+
+	oldPublicID := channel.WatermarkPublicID
+	channel.Watermark, channel.WatermarkPublicID = "", ""
+
+	m.saveChannelBranding(c, channel, "", oldPublicID, "Watermark removed successfully!")
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihan2bd/vidverse/helpers"
+	"github.com/raihan2bd/vidverse/models"
+	validator "github.com/raihan2bd/vidverse/validators"
+)
+
+// HandleGetCategories get the list of video categories
+func (m *Repo) HandleGetCategories(c *gin.Context) {
+	c.JSON(200, gin.H{"categories": models.VideoCategories})
+}
+
+// HandleGetVideosByCategory get the videos of a category with pagination
+func (m *Repo) HandleGetVideosByCategory(c *gin.Context) {
+	category := strings.ToLower(c.Param("category"))
+
+	v := validator.New()
+	v.IsIn(category, "category", models.VideoCategories, "404 category not found!")
+	if !v.Valid() {
+		c.JSON(404, gin.H{"error": v.GetErrMsg()})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(400, gin.H{"error": "invalid page number"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "24"))
+	if err != nil || limit < 1 {
+		c.JSON(400, gin.H{"error": "invalid limit number"})
+		return
+	}
+
+	videos, total, err := m.App.DBMethods.GetVideosByCategory(category, page, limit)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	var has_next_page bool
+	if total > int64(page*limit) {
+		has_next_page = true
+	}
+
+	c.JSON(200, gin.H{"category": category, "videos": videos, "total": total, "has_next_page": has_next_page, "page": page})
+}
+
+// HandleGetVideosByTag get the videos of a tag with pagination
+func (m *Repo) HandleGetVideosByTag(c *gin.Context) {
+	tags := helpers.ParseTags(c.Param("tag"))
+	if len(tags) != 1 {
+		c.JSON(404, gin.H{"error": "404 tag not found!"})
+		return
+	}
+	tag := tags[0]
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(400, gin.H{"error": "invalid page number"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "24"))
+	if err != nil || limit < 1 {
+		c.JSON(400, gin.H{"error": "invalid limit number"})
+		return
+	}
+
+	videos, total, err := m.App.DBMethods.GetVideosByTag(tag, page, limit)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	var has_next_page bool
+	if total > int64(page*limit) {
+		has_next_page = true
+	}
+
+	c.JSON(200, gin.H{"tag": tag, "videos": videos, "total": total, "has_next_page": has_next_page, "page": page})
+}
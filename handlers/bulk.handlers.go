@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihan2bd/vidverse/helpers"
+	"github.com/raihan2bd/vidverse/models"
+	validator "github.com/raihan2bd/vidverse/validators"
+)
+
+// the most videos a single bulk request can change
+const maxBulkVideos = 100
+
+// HandleBulkUpdateVideos change the visibility, move, tag or delete many videos of the user's channels at once
+func (m *Repo) HandleBulkUpdateVideos(c *gin.Context) {
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Access denied! Please login first"})
+		return
+	}
+
+	user, err := m.App.DBMethods.GetUserByID(uint(user_id.(float64)))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Access denied! Please login first"})
+		return
+	}
+
+	var payload struct {
+		VideoIDs   []uint   `json:"video_ids"`
+		Action     string   `json:"action"`
+		Visibility string   `json:"visibility"`
+		ChannelID  uint     `json:"channel_id"`
+		Tags       []string `json:"tags"`
+	}
+
+	err = c.BindJSON(&payload)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "Invalid bulk payload"})
+		return
+	}
+
+	// the same video is only changed once
+	videoIDs := make([]uint, 0, len(payload.VideoIDs))
+	seen := map[uint]bool{}
+	for _, id := range payload.VideoIDs {
+		if id > 0 && !seen[id] {
+			seen[id] = true
+			videoIDs = append(videoIDs, id)
+		}
+	}
+
+	validator := validator.New()
+	validator.Check(len(videoIDs) > 0 && len(videoIDs) <= maxBulkVideos, "video_ids", "Please select between 1 and 100 videos")
+	validator.IsIn(payload.Action, "action", models.BulkVideoActions, "Invalid action. Action must be visibility, move, add_tags or delete")
+
+	action := &models.BulkVideoAction{Action: payload.Action}
+	var tags []string
+	switch payload.Action {
+	case models.BulkActionVisibility:
+		validator.IsIn(payload.Visibility, "visibility", models.VideoVisibilities, "Invalid visibility. Visibility must be public, unlisted, private or draft")
+		action.Visibility = payload.Visibility
+	case models.BulkActionMove:
+		validator.Check(payload.ChannelID > 0, "channel_id", "channel_id is required")
+		action.ChannelID = payload.ChannelID
+	case models.BulkActionAddTags:
+		for _, tag := range payload.Tags {
+			tags = append(tags, helpers.ParseTags(tag)...)
+		}
+		validator.Check(len(tags) > 0, "tags", "tags are required")
+		validator.IsTags(tags, "tags", 10)
+	}
+
+	if !validator.Valid() {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": validator.GetErrMsg()})
+		return
+	}
+
+	isAdmin := m.isAdmin(c, user)
+
+	// videos can only be moved to a channel the user also manages the videos of
+	if action.Action == models.BulkActionMove {
+		channel, err := m.App.DBMethods.GetChannelByID(int(action.ChannelID))
+		if err != nil || channel.ID == 0 {
+			c.IndentedJSON(http.StatusNotFound, gin.H{"error": "The channel you want to move the videos to is not found!"})
+			return
+		}
+
+		if !models.RoleCan(m.channelRole(c, user, channel.ID), models.PermManageVideos) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied! You are not allowed to move videos to this channel"})
+			return
+		}
+	}
+
+	if action.Action == models.BulkActionAddTags {
+		action.Tags, err = m.App.DBMethods.FindOrCreateTags(tags)
+		if err != nil {
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "Failed to save the tags"})
+			return
+		}
+	}
+
+	results, err := m.App.DBMethods.BulkUpdateVideos(user.ID, isAdmin, videoIDs, action)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": "Failed to update the videos"})
+		return
+	}
+
+	var succeeded int
+	for _, result := range results {
+		if !result.Success {
+			continue
+		}
+		succeeded++
+
+		// the tags or the channel may have changed, rank the related videos again
+		m.App.Recommender.Invalidate(result.VideoID)
+
+		if result.Published {
+			go helpers.NotifySubscribers(m.App, result.VideoID)
+		}
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{
+		"results":   results,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+	})
+}
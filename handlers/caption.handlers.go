@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihan2bd/vidverse/internal/media"
+	"github.com/raihan2bd/vidverse/models"
+	validator "github.com/raihan2bd/vidverse/validators"
+)
+
+// language tags like "en", "pt-BR" or "zh-Hans"
+var captionLanguage = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})?$`)
+
+// HandleUploadCaption upload a SRT or WebVTT subtitle file for a language of a video
+func (m *Repo) HandleUploadCaption(c *gin.Context) {
+	videoID, err := strconv.Atoi(c.Param("videoID"))
+	if err != nil || videoID <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 video not found!"})
+		return
+	}
+
+	video, err := m.App.DBMethods.FindVideoByID(uint(videoID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 video not found!"})
+		return
+	}
+
+	if !m.canManageVideo(c, video) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied! You are not allowed to update this video"})
+		return
+	}
+
+	file, fileInfo, err := c.Request.FormFile("caption")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "caption file is required."})
+		return
+	}
+	defer file.Close()
+
+	language := strings.TrimSpace(c.PostForm("language"))
+	label := strings.TrimSpace(c.DefaultPostForm("label", language))
+	ext := strings.ToLower(filepath.Ext(fileInfo.Filename))
+
+	validator := validator.New()
+	validator.Check(captionLanguage.MatchString(language), "language", "Invalid language. Please use a language code like en or pt-BR")
+	validator.IsLength(label, "label", 1, 50)
+	validator.Check(ext == ".srt" || ext == ".vtt", "caption", "Invalid caption file. Only .srt and .vtt files are allowed")
+	validator.Check(fileInfo.Size <= 1024*1024, "caption", "caption file must be less than 1MB")
+
+	if !validator.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validator.GetErrMsg()})
+		return
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read the caption file"})
+		return
+	}
+
+	cues, err := media.ParseCaptions(data)
+	if err == nil {
+		err = media.ValidateCues(cues, video.Duration)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid caption file. %s", err.Error())})
+		return
+	}
+
+	caption, err := m.App.DBMethods.SaveCaption(&models.Caption{
+		VideoID:  video.ID,
+		Language: language,
+		Label:    label,
+		Track:    media.BuildCaptionTrack(cues),
+		Text:     media.CaptionText(cues),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"caption": caption, "cues": len(cues)})
+}
+
+// HandleGetCaptions get the caption tracks of a video
+func (m *Repo) HandleGetCaptions(c *gin.Context) {
+	video, ok := m.findViewableVideo(c)
+	if !ok {
+		return
+	}
+
+	captions, err := m.App.DBMethods.GetCaptionsByVideoID(video.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	tracks := make([]gin.H, 0, len(captions))
+	for _, caption := range captions {
+		tracks = append(tracks, gin.H{
+			"id":       caption.ID,
+			"language": caption.Language,
+			"label":    caption.Label,
+			"src":      fmt.Sprintf("/api/v1/videos/%d/captions/%s", video.ID, caption.Language),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"captions": tracks})
+}
+
+// HandleGetCaption download the WebVTT caption track of a video in a language
+func (m *Repo) HandleGetCaption(c *gin.Context) {
+	video, ok := m.findViewableVideo(c)
+	if !ok {
+		return
+	}
+
+	caption, err := m.App.DBMethods.GetCaption(video.ID, c.Param("language"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 caption not found!"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"%d.%s.vtt\"", video.ID, caption.Language))
+	c.Data(http.StatusOK, "text/vtt; charset=utf-8", []byte(caption.Track))
+}
+
+// HandleDeleteCaption delete the caption track of a video in a language
+func (m *Repo) HandleDeleteCaption(c *gin.Context) {
+	videoID, err := strconv.Atoi(c.Param("videoID"))
+	if err != nil || videoID <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 video not found!"})
+		return
+	}
+
+	video, err := m.App.DBMethods.FindVideoByID(uint(videoID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 video not found!"})
+		return
+	}
+
+	if !m.canManageVideo(c, video) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied! You are not allowed to update this video"})
+		return
+	}
+
+	err = m.App.DBMethods.DeleteCaption(video.ID, c.Param("language"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 caption not found!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "caption deleted successfully"})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/raihan2bd/vidverse/helpers"
@@ -22,21 +23,13 @@ func (m *Repo) HandleGetChannelsWithDetailsByUserID(c *gin.Context) {
 
 	// convert user id to uint
 	userID := uint(user_id.(float64))
-	var user *models.User
-	user, err := m.App.DBMethods.GetUserByID(userID)
+	_, err := m.App.DBMethods.GetUserByID(userID)
 	if err != nil {
 		c.JSON(401, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	// validate user role
-	if user.UserRole != "admin" {
-		if user.UserRole != "author" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "You are not authorized to get channels"})
-			return
-		}
-	}
-
+	// authors get their own channels and any user gets the channels they are a member of
 	var channels []models.CustomChannelDTO
 	channels, err = m.App.DBMethods.GetChannelsWithDetailsByUserID(userID)
 	if err != nil {
@@ -152,6 +145,13 @@ func (m *Repo) HandleCreateChannel(c *gin.Context) {
 	validator.IsImage(logoHeader.Header.Get("Content-Type"), "logo")
 	validator.IsImageSize(logoHeader.Size, 5*1024*1024, "logo")
 
+	// the cover is optional, the default cover is used without it
+	coverFile, coverHeader, _ := c.Request.FormFile("cover")
+	if coverFile != nil {
+		defer coverFile.Close()
+		validateCover(coverFile, coverHeader, validator)
+	}
+
 	// get channel title from form
 	title := c.PostForm("title")
 	validator.Required(title, "title", "title is required.")
@@ -162,6 +162,12 @@ func (m *Repo) HandleCreateChannel(c *gin.Context) {
 	validator.Required(description, "description", "description is required")
 	validator.IsLength(description, "description", 25, 500)
 
+	// the handle is optional, one is made from the title when it is missing
+	handle := helpers.NormalizeHandle(c.PostForm("handle"))
+	if handle != "" {
+		validator.IsHandle(handle, "handle")
+	}
+
 	if !validator.Valid() {
 		c.IndentedJSON(http.StatusBadRequest, gin.H{
 			"error": validator.GetErrMsg(),
@@ -169,6 +175,24 @@ func (m *Repo) HandleCreateChannel(c *gin.Context) {
 		return
 	}
 
+	if handle == "" {
+		handle, err = m.uniqueHandle(title)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to create the channel handle"})
+			return
+		}
+	} else {
+		available, err := m.App.DBMethods.IsHandleAvailable(handle, 0)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "internal server error"})
+			return
+		}
+		if !available {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("The handle @%s is already taken", handle)})
+			return
+		}
+	}
+
 	ctx := context.Background()
 
 	// upload logo to cloudinary
@@ -183,20 +207,32 @@ func (m *Repo) HandleCreateChannel(c *gin.Context) {
 		return
 	}
 
-	channel := models.Channel{Title: title, Description: description, Logo: secureURL, UserID: user.ID, LogoPublicID: publicID}
+	channel := models.Channel{Title: title, Handle: handle, Description: description, Logo: secureURL, UserID: user.ID, LogoPublicID: publicID}
+
+	if coverFile != nil {
+		channel.Cover, channel.CoverPublicID, err = helpers.UploadImageToCloudinary(ctx, m.App.CLD, coverFile, channelCoverFolder)
+		if err != nil {
+			_ = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, publicID)
+			c.JSON(500, gin.H{"error": "Failed to upload cover"})
+			return
+		}
+	}
 
 	var id uint
 	id, err = m.App.DBMethods.CreateChannel(&channel)
 	if err != nil {
-		// delete logo from cloudinary if exists
+		// delete logo and cover from cloudinary if exists
 		ctx := context.Background()
 		_ = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, publicID)
+		if channel.CoverPublicID != "" {
+			_ = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, channel.CoverPublicID)
+		}
 
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(200, gin.H{"message": "Channel created successfully", "channel_id": id})
+	c.JSON(200, gin.H{"message": "Channel created successfully", "channel_id": id, "handle": handle})
 }
 
 // edit channel
@@ -241,6 +277,13 @@ func (m *Repo) HandleEditChannel(c *gin.Context) {
 		validator.IsImageSize(logoHeader.Size, 5*1024*1024, "logo")
 	}
 
+	// validate cover if exists
+	coverFile, coverHeader, _ := c.Request.FormFile("cover")
+	if coverFile != nil {
+		defer coverFile.Close()
+		validateCover(coverFile, coverHeader, validator)
+	}
+
 	if !validator.Valid() {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": validator.GetErrMsg(),
@@ -264,17 +307,15 @@ func (m *Repo) HandleEditChannel(c *gin.Context) {
 		return
 	}
 
-	// check user role
-	if user.UserRole != "admin" {
-		if user.ID != channel.UserID {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "You are not authorized to edit channel"})
-			return
-		}
+	// check the role of the user in the channel
+	if !models.RoleCan(m.channelRole(c, user, channel.ID), models.PermManageChannel) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "You are not authorized to edit channel"})
+		return
 	}
 
 	// check if data is the same of not
 	if title == channel.Title && description == channel.Description && logoURL == channel.Logo {
-		if logoHeader == nil {
+		if logoHeader == nil && coverFile == nil {
 			c.JSON(200, gin.H{"message": "Your channel is already up to date!"})
 			return
 		}
@@ -302,6 +343,20 @@ func (m *Repo) HandleEditChannel(c *gin.Context) {
 		channel.Logo = secureURL
 	}
 
+	// upload cover to cloudinary
+	oldCoverPublicID := channel.CoverPublicID
+	if coverFile != nil {
+		ctx := context.Background()
+		channel.Cover, channel.CoverPublicID, err = helpers.UploadImageToCloudinary(ctx, m.App.CLD, coverFile, channelCoverFolder)
+		if err != nil {
+			if secureURL != "" {
+				_ = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, publicID)
+			}
+			c.JSON(500, gin.H{"error": "Failed to upload cover"})
+			return
+		}
+	}
+
 	if title != "" && title != channel.Title {
 		channel.Title = title
 	}
@@ -317,6 +372,10 @@ func (m *Repo) HandleEditChannel(c *gin.Context) {
 			ctx := context.Background()
 			_ = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, publicID)
 		}
+		if channel.CoverPublicID != oldCoverPublicID {
+			ctx := context.Background()
+			_ = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, channel.CoverPublicID)
+		}
 
 		c.JSON(500, gin.H{"error": "Failed to update channel"})
 		return
@@ -324,6 +383,11 @@ func (m *Repo) HandleEditChannel(c *gin.Context) {
 
 	c.JSON(201, gin.H{"message": "Channel updated successfully!"})
 
+	if oldCoverPublicID != "" && oldCoverPublicID != channel.CoverPublicID {
+		ctx := context.Background()
+		_ = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, oldCoverPublicID)
+	}
+
 	if oldPublicID != channel.LogoPublicID {
 		ctx := context.Background()
 		err = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, oldPublicID)
@@ -365,36 +429,20 @@ func (m *Repo) HandleDeleteChannel(c *gin.Context) {
 		return
 	}
 
-	// check user role
-	if user.UserRole != "admin" {
-		if user.ID != channel.UserID {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "You are not authorized to delete channel"})
-			return
-		}
-	}
-
-	// // delete channel
-	// deleteError := m.App.DBMethods.DeleteChannelByID(channelID)
-
-	// if deleteError != nil {
-	// 	c.JSON(deleteError.Status, gin.H{"error": deleteError.Err.Error()})
-	// 	return
-	// }
-
-	customErr := m.App.DBMethods.DeleteChannelByID(channelID)
-	if customErr != nil {
-		c.JSON(customErr.Status, gin.H{"error": customErr.Err.Error()})
+	// only the owner can delete the channel
+	if !models.RoleCan(m.channelRole(c, user, channel.ID), models.PermDeleteChannel) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "You are not authorized to delete channel"})
 		return
 	}
 
-	c.JSON(200, gin.H{"message": "Channel deleted successfully"})
-
-	// delete notification by channelID
-	err = m.App.DBMethods.DeleteNotificationsByChannelID(channel.ID)
+	// move the channel with its videos to the trash, it is deleted for good after the retention
+	err = m.App.DBMethods.TrashChannel(channel.ID)
 	if err != nil {
-		fmt.Println(err)
+		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
+
+	c.JSON(200, gin.H{"message": "The channel has been moved to the trash", "purge_at": time.Now().Add(m.App.TrashRetention)})
 }
 
 // GetVideos by channel id
@@ -437,6 +485,49 @@ func (m *Repo) HandleGetChannelsVideos(c *gin.Context) {
 	c.JSON(200, gin.H{"videos": videos, "has_next_page": hasNext, "page": page, "total_videos": count})
 }
 
+// HandleGetManagedChannelVideos list every video of a channel to its team, including the private, draft and unfinished ones
+func (m *Repo) HandleGetManagedChannelVideos(c *gin.Context) {
+	channel, ok := m.findManagedChannel(c, models.PermManageVideos)
+	if !ok {
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(400, gin.H{"error": "invalid page number"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "16"))
+	if err != nil || limit < 1 {
+		c.JSON(400, gin.H{"error": "invalid limit number"})
+		return
+	}
+
+	visibility := c.Query("visibility")
+	status := c.Query("status")
+
+	validator := validator.New()
+	if visibility != "" {
+		validator.IsIn(visibility, "visibility", models.VideoVisibilities, "Invalid visibility. Visibility must be public, unlisted, private or draft")
+	}
+	if status != "" {
+		validator.IsIn(status, "status", models.VideoStatuses, "Invalid status. Status must be uploading, processing, ready or failed")
+	}
+	if !validator.Valid() {
+		c.JSON(400, gin.H{"error": validator.GetErrMsg()})
+		return
+	}
+
+	videos, count, err := m.App.DBMethods.GetManagedChannelVideos(channel.ID, visibility, status, page, limit)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to get videos"})
+		return
+	}
+
+	c.JSON(200, gin.H{"videos": videos, "has_next_page": count > int64(limit)*int64(page), "page": page, "total_videos": count})
+}
+
 // Get channel by id with details
 func (m *Repo) HandleGetChannelWithDetails(c *gin.Context) {
 	user_id, _ := c.Get("user_id")
@@ -463,3 +554,56 @@ func (m *Repo) HandleGetChannelWithDetails(c *gin.Context) {
 
 	c.JSON(200, gin.H{"channel": channel})
 }
+
+// an admin only has admin rights with a login that passed the two-factor check, never with an API key
+func (m *Repo) isAdmin(c *gin.Context, user *models.User) bool {
+	return user.UserRole == "admin" && c.GetBool("mfa")
+}
+
+// the role of a user in a channel, admins act as the owner of every channel
+func (m *Repo) channelRole(c *gin.Context, user *models.User, channelID uint) string {
+	if m.isAdmin(c, user) {
+		return models.RoleOwner
+	}
+
+	role, err := m.App.DBMethods.GetChannelRole(channelID, user.ID)
+	if err != nil {
+		return ""
+	}
+
+	return role
+}
+
+// find the channel of the request, only the members whose role has the permission and admins can manage it
+func (m *Repo) findManagedChannel(c *gin.Context, permission string) (*models.CustomChannelDTO, bool) {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid channel id"})
+		return nil, false
+	}
+
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return nil, false
+	}
+
+	user, err := m.App.DBMethods.GetUserByID(uint(user_id.(float64)))
+	if err != nil {
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return nil, false
+	}
+
+	channel, err := m.App.DBMethods.GetChannelByID(channelID)
+	if err != nil || channel.ID == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 channel not found!"})
+		return nil, false
+	}
+
+	if !models.RoleCan(m.channelRole(c, user, channel.ID), permission) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied! You are not allowed to manage this channel"})
+		return nil, false
+	}
+
+	return channel, true
+}
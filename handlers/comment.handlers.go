@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/raihan2bd/vidverse/config"
+	"github.com/raihan2bd/vidverse/helpers"
 	"github.com/raihan2bd/vidverse/models"
 	validator "github.com/raihan2bd/vidverse/validators"
 )
@@ -139,7 +140,7 @@ func (m *Repo) HandleCreateOrUpdateComment(c *gin.Context) {
 		}
 
 		if comment.UserID != user.ID {
-			if user.UserRole != "admin" {
+			if !m.isAdmin(c, user) {
 				c.IndentedJSON(403, gin.H{
 					"error": "You are not allowed to update this comment",
 				})
@@ -183,6 +184,15 @@ func (m *Repo) HandleCreateOrUpdateComment(c *gin.Context) {
 			"id":      comment_id,
 		})
 
+		m.recordEvent(models.EventComment, video.ChannelID, video.ID, user.ID)
+		helpers.DispatchWebhookEvent(m.App, video.ChannelID, models.EventCommentCreated, map[string]interface{}{
+			"comment_id": comment_id,
+			"video_id":   video.ID,
+			"user_id":    user.ID,
+			"user_name":  user.Name,
+			"text":       comment.Text,
+		})
+
 		if user.ID == video.Channel.UserID {
 			return
 		}
@@ -252,7 +262,7 @@ func (m *Repo) HandleDeleteComment(c *gin.Context) {
 	}
 
 	if comment.UserID != user.ID {
-		if user.UserRole != "admin" {
+		if !m.isAdmin(c, user) {
 			c.IndentedJSON(400, gin.H{
 				"error": "You are not allowed to delete this comment",
 			})
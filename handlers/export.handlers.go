@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// the rows written before the response is flushed to the client
+const exportFlushEvery = 100
+
+// exporter writes the rows of an export to the response as CSV or NDJSON while they are read
+type exporter struct {
+	c    *gin.Context
+	csv  *csv.Writer
+	json *json.Encoder
+	rows int
+}
+
+// start the export response, only csv and ndjson formats are supported
+func newExporter(c *gin.Context, channelID uint, name string, header []string) (*exporter, bool) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "ndjson" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format. Format must be csv or ndjson"})
+		return nil, false
+	}
+
+	e := &exporter{c: c}
+	filename := fmt.Sprintf("channel-%d-%s-%s.%s", channelID, name, time.Now().UTC().Format("20060102"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		e.csv = csv.NewWriter(c.Writer)
+		_ = e.csv.Write(header)
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+		e.json = json.NewEncoder(c.Writer)
+	}
+
+	c.Status(http.StatusOK)
+	return e, true
+}
+
+// write a row as the CSV record or as a JSON line
+func (e *exporter) write(record []string, row interface{}) error {
+	var err error
+	if e.csv != nil {
+		// spreadsheets run cells starting with these characters as formulas
+		for i, value := range record {
+			if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+				record[i] = "'" + value
+			}
+		}
+		err = e.csv.Write(record)
+	} else {
+		err = e.json.Encode(row)
+	}
+	if err != nil {
+		return err
+	}
+
+	e.rows++
+	if e.rows%exportFlushEvery == 0 {
+		e.flush()
+	}
+
+	return nil
+}
+
+func (e *exporter) flush() {
+	if e.csv != nil {
+		e.csv.Flush()
+	}
+	e.c.Writer.Flush()
+}
+
+// finish the export, the status is already sent so a failure can only be logged
+func (e *exporter) close(err error) {
+	if err != nil {
+		log.Println(err)
+	}
+	e.flush()
+}
+
+func formatExportTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// HandleExportChannelVideos export the videos of a channel with their views, likes and comments
+func (m *Repo) HandleExportChannelVideos(c *gin.Context) {
+	channel, ok := m.findManagedChannel(c, models.PermViewAnalytics)
+	if !ok {
+		return
+	}
+
+	e, ok := newExporter(c, channel.ID, "videos", []string{"id", "title", "visibility", "views", "likes", "comments", "created_at"})
+	if !ok {
+		return
+	}
+
+	err := m.App.DBMethods.StreamChannelVideos(channel.ID, func(row *models.VideoExportRow) error {
+		return e.write([]string{
+			strconv.FormatUint(uint64(row.ID), 10),
+			row.Title,
+			row.Visibility,
+			strconv.FormatInt(row.Views, 10),
+			strconv.FormatInt(row.Likes, 10),
+			strconv.FormatInt(row.Comments, 10),
+			formatExportTime(row.CreatedAt),
+		}, row)
+	})
+	e.close(err)
+}
+
+// HandleExportChannelSubscribers export the new and total subscribers of a channel per day
+func (m *Repo) HandleExportChannelSubscribers(c *gin.Context) {
+	channel, ok := m.findManagedChannel(c, models.PermViewAnalytics)
+	if !ok {
+		return
+	}
+
+	e, ok := newExporter(c, channel.ID, "subscribers", []string{"day", "new_subscribers", "total_subscribers"})
+	if !ok {
+		return
+	}
+
+	err := m.App.DBMethods.StreamChannelSubscribers(channel.ID, func(row *models.SubscriberExportRow) error {
+		return e.write([]string{
+			row.Day.Format("2006-01-02"),
+			strconv.FormatInt(row.NewSubscribers, 10),
+			strconv.FormatInt(row.TotalSubscribers, 10),
+		}, row)
+	})
+	e.close(err)
+}
+
+// HandleExportChannelComments export the comments on the videos of a channel
+func (m *Repo) HandleExportChannelComments(c *gin.Context) {
+	channel, ok := m.findManagedChannel(c, models.PermViewAnalytics)
+	if !ok {
+		return
+	}
+
+	e, ok := newExporter(c, channel.ID, "comments", []string{"id", "video_id", "video_title", "user_name", "text", "created_at"})
+	if !ok {
+		return
+	}
+
+	err := m.App.DBMethods.StreamChannelComments(channel.ID, func(row *models.CommentExportRow) error {
+		return e.write([]string{
+			strconv.FormatUint(uint64(row.ID), 10),
+			strconv.FormatUint(uint64(row.VideoID), 10),
+			row.VideoTitle,
+			row.UserName,
+			row.Text,
+			formatExportTime(row.CreatedAt),
+		}, row)
+	})
+	e.close(err)
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// HandleGetSubscriptionFeed get the latest videos from the channels the user is subscribed to
+func (m *Repo) HandleGetSubscriptionFeed(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(401, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var (
+		err         error
+		page, limit int
+	)
+
+	page, err = strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(400, gin.H{"error": "invalid page number"})
+		return
+	}
+
+	limit, err = strconv.Atoi(c.DefaultQuery("limit", "24"))
+	if err != nil || limit < 1 {
+		c.JSON(400, gin.H{"error": "invalid limit number"})
+		return
+	}
+
+	userIDUint := uint(userID.(float64))
+
+	var (
+		videos []models.VideoDTO
+		total  int64
+	)
+
+	videos, total, err = m.App.DBMethods.GetSubscriptionFeed(userIDUint, page, limit)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	var has_next_page bool
+	if total > int64(page*limit) {
+		has_next_page = true
+	}
+
+	c.JSON(200, gin.H{"videos": videos, "total": total, "has_next_page": has_next_page, "page": page})
+
+	// the user has seen the newest uploads, reset the unseen counters
+	if page == 1 {
+		err = m.App.DBMethods.MarkSubscriptionsAsSeen(userIDUint)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// HandleGetSubscribedChannels get all the channels the user is subscribed to
+func (m *Repo) HandleGetSubscribedChannels(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(401, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	userIDUint := uint(userID.(float64))
+
+	channels, err := m.App.DBMethods.GetSubscribedChannelsByUserID(userIDUint)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(200, gin.H{"channels": channels, "total": len(channels)})
+}
+
+// HandleGetTrendingVideos get the trending videos
+func (m *Repo) HandleGetTrendingVideos(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(400, gin.H{"error": "invalid page number"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "24"))
+	if err != nil || limit < 1 {
+		c.JSON(400, gin.H{"error": "invalid limit number"})
+		return
+	}
+
+	videos, total, err := m.App.DBMethods.GetTrendingVideos(page, limit)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+
+	var has_next_page bool
+	if total > int64(page*limit) {
+		has_next_page = true
+	}
+
+	c.JSON(200, gin.H{"videos": videos, "total": total, "has_next_page": has_next_page, "page": page})
+}
+
+// how deep the home feed can be paged
+const maxFeedDepth = 500
+
+// HandleGetHomeFeed get the home feed mixing trending, subscriptions and fresh uploads
+func (m *Repo) HandleGetHomeFeed(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(400, gin.H{"error": "invalid page number"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "24"))
+	if err != nil || limit < 1 || limit > maxFeedDepth {
+		c.JSON(400, gin.H{"error": "invalid limit number"})
+		return
+	}
+
+	// the feed is mixed from the start on every page so a video never shows up on two pages
+	depth := page * limit
+	if depth > maxFeedDepth {
+		c.JSON(200, gin.H{"videos": []models.VideoDTO{}, "has_next_page": false, "page": page})
+		return
+	}
+
+	var (
+		sources       [][]models.VideoDTO
+		has_next_page bool
+	)
+
+	// every source fills the whole feed on its own so the mix never runs short
+	if userID, ok := c.Get("user_id"); ok {
+		subscribed, total, err := m.App.DBMethods.GetSubscriptionFeed(uint(userID.(float64)), 1, depth)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "internal server error"})
+			return
+		}
+		sources = append(sources, subscribed)
+		has_next_page = has_next_page || total > int64(depth)
+	}
+
+	trending, total, err := m.App.DBMethods.GetTrendingVideos(1, depth)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+	sources = append(sources, trending)
+	has_next_page = has_next_page || total > int64(depth)
+
+	latest, total, err := m.App.DBMethods.GetLatestVideos(1, depth)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "internal server error"})
+		return
+	}
+	sources = append(sources, latest)
+	has_next_page = (has_next_page || total > int64(depth)) && depth < maxFeedDepth
+
+	videos := mixFeeds(depth, sources...)
+	if len(videos) > (page-1)*limit {
+		videos = videos[(page-1)*limit:]
+	} else {
+		videos = []models.VideoDTO{}
+	}
+
+	c.JSON(200, gin.H{"videos": videos, "has_next_page": has_next_page, "page": page})
+}
+
+// take one video from every source in turn, skipping the videos already picked
+func mixFeeds(limit int, sources ...[]models.VideoDTO) []models.VideoDTO {
+	videos := make([]models.VideoDTO, 0, limit)
+	seen := map[uint]bool{}
+
+	for i := 0; len(videos) < limit; i++ {
+		added := false
+		for _, source := range sources {
+			if i >= len(source) {
+				continue
+			}
+			added = true
+			if seen[source[i].ID] || len(videos) >= limit {
+				continue
+			}
+			seen[source[i].ID] = true
+			videos = append(videos, source[i])
+		}
+
+		if !added {
+			break
+		}
+	}
+
+	return videos
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/raihan2bd/vidverse/models"
+)
+
+func videoIDs(videos []models.VideoDTO) []uint {
+	ids := make([]uint, 0, len(videos))
+	for _, video := range videos {
+		ids = append(ids, video.ID)
+	}
+	return ids
+}
+
+func videoDTOs(ids ...uint) []models.VideoDTO {
+	videos := make([]models.VideoDTO, 0, len(ids))
+	for _, id := range ids {
+		videos = append(videos, models.VideoDTO{ID: id})
+	}
+	return videos
+}
+
+func TestMixFeeds(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int
+		sources [][]models.VideoDTO
+		want    []uint
+	}{
+		{
+			name:  "no sources",
+			limit: 5,
+			want:  []uint{},
+		},
+		{
+			name:    "take one from every source in turn",
+			limit:   6,
+			sources: [][]models.VideoDTO{videoDTOs(1, 2, 3), videoDTOs(4, 5, 6)},
+			want:    []uint{1, 4, 2, 5, 3, 6},
+		},
+		{
+			name:    "skip duplicates",
+			limit:   5,
+			sources: [][]models.VideoDTO{videoDTOs(1, 2, 3), videoDTOs(1, 4, 2)},
+			want:    []uint{1, 2, 4, 3},
+		},
+		{
+			name:    "stop at the limit",
+			limit:   3,
+			sources: [][]models.VideoDTO{videoDTOs(1, 2, 3), videoDTOs(4, 5, 6)},
+			want:    []uint{1, 4, 2},
+		},
+		{
+			name:    "continue with the longer source",
+			limit:   5,
+			sources: [][]models.VideoDTO{videoDTOs(1), nil, videoDTOs(2, 3, 4, 5)},
+			want:    []uint{1, 2, 3, 4, 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := videoIDs(mixFeeds(tt.limit, tt.sources...))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mixFeeds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMixFeedsPagesDoNotOverlap(t *testing.T) {
+	trending := videoDTOs(1, 2, 3, 4, 5, 6)
+	latest := videoDTOs(6, 5, 7, 8, 9, 1)
+	limit := 3
+
+	seen := map[uint]bool{}
+	for page := 1; page <= 3; page++ {
+		depth := page * limit
+		videos := mixFeeds(depth, trending[:min(depth, len(trending))], latest[:min(depth, len(latest))])
+		if len(videos) <= (page-1)*limit {
+			break
+		}
+		for _, id := range videoIDs(videos[(page-1)*limit:]) {
+			if seen[id] {
+				t.Fatalf("video %d shows up on more than one page", id)
+			}
+			seen[id] = true
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihan2bd/vidverse/helpers"
+	"github.com/raihan2bd/vidverse/models"
+	validator "github.com/raihan2bd/vidverse/validators"
+)
+
+// how often a channel can change its handle and how long the old handle keeps redirecting
+const (
+	handleCooldown    = 30 * 24 * time.Hour
+	handleGracePeriod = 14 * 24 * time.Hour
+)
+
+// ResolveChannelHandle let the channel routes take a @handle wherever they take a channel ID
+func (m *Repo) ResolveChannelHandle(c *gin.Context) {
+	raw := c.Param("channelID")
+	if _, err := strconv.Atoi(raw); err == nil {
+		c.Next()
+		return
+	}
+
+	handle := helpers.NormalizeHandle(raw)
+	channelID, current, err := m.App.DBMethods.FindChannelByHandle(handle)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "404 Channel not found!"})
+		return
+	}
+
+	// an old handle sends the pages to the new one while its grace period lasts
+	if current != handle && c.Request.Method == http.MethodGet {
+		location := strings.Replace(c.Request.URL.Path, "/"+raw, "/@"+current, 1)
+		if c.Request.URL.RawQuery != "" {
+			location += "?" + c.Request.URL.RawQuery
+		}
+		c.Redirect(http.StatusMovedPermanently, location)
+		c.Abort()
+		return
+	}
+
+	for i, param := range c.Params {
+		if param.Key == "channelID" {
+			c.Params[i].Value = strconv.Itoa(int(channelID))
+		}
+	}
+
+	c.Next()
+}
+
+// resolve a channel ID or a @handle sent in a form to the channel ID
+func (m *Repo) resolveChannelID(raw string) (int, error) {
+	if id, err := strconv.Atoi(raw); err == nil {
+		return id, nil
+	}
+
+	channelID, _, err := m.App.DBMethods.FindChannelByHandle(helpers.NormalizeHandle(raw))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(channelID), nil
+}
+
+// find a free handle for a new channel starting from its title
+func (m *Repo) uniqueHandle(title string) (string, error) {
+	base := helpers.SuggestHandle(title)
+
+	for i := 0; i < 50; i++ {
+		handle := base
+		if i > 0 {
+			handle = fmt.Sprintf("%s%d", base, i)
+		}
+
+		// a title made of reserved or banned words falls back to a neutral handle
+		v := validator.New()
+		v.IsHandle(handle, "handle")
+		if !v.Valid() {
+			if i == 0 {
+				base = "channel"
+			}
+			continue
+		}
+
+		available, err := m.App.DBMethods.IsHandleAvailable(handle, 0)
+		if err != nil {
+			return "", err
+		}
+		if available {
+			return handle, nil
+		}
+	}
+
+	return "", fmt.Errorf("failed to find a free handle for %q", title)
+}
+
+// HandleCheckHandle check a handle is valid and free before the user picks it
+func (m *Repo) HandleCheckHandle(c *gin.Context) {
+	handle := helpers.NormalizeHandle(c.Param("handle"))
+
+	v := validator.New()
+	v.IsHandle(handle, "handle")
+	if !v.Valid() {
+		c.JSON(http.StatusOK, gin.H{"handle": handle, "available": false, "error": v.GetErrMsg()})
+		return
+	}
+
+	var channelID int
+	if raw := c.Query("channel_id"); raw != "" {
+		channelID, _ = strconv.Atoi(raw)
+	}
+
+	available, err := m.App.DBMethods.IsHandleAvailable(handle, uint(channelID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"handle": handle, "available": available})
+}
+
+// HandleUpdateChannelHandle change the handle of a channel, the old handle keeps redirecting for a grace period
+func (m *Repo) HandleUpdateChannelHandle(c *gin.Context) {
+	channel, ok := m.findManagedChannel(c, models.PermManageChannel)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Handle string `json:"handle"`
+	}
+
+	err := c.BindJSON(&payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid handle payload"})
+		return
+	}
+
+	handle := helpers.NormalizeHandle(payload.Handle)
+	if handle == channel.Handle {
+		c.JSON(http.StatusOK, gin.H{"message": "The handle is not changed", "handle": handle})
+		return
+	}
+
+	v := validator.New()
+	v.IsHandle(handle, "handle")
+	if !v.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": v.GetErrMsg()})
+		return
+	}
+
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, err := m.App.DBMethods.GetUserByID(uint(user_id.(float64)))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if !m.isAdmin(c, user) && channel.HandleChangedAt != nil {
+		if next := channel.HandleChangedAt.Add(handleCooldown); time.Now().Before(next) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": fmt.Sprintf("You can change the handle again after %s", next.Format("2006-01-02"))})
+			return
+		}
+	}
+
+	available, err := m.App.DBMethods.IsHandleAvailable(handle, channel.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	if !available {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("The handle @%s is already taken", handle)})
+		return
+	}
+
+	redirectUntil := time.Now().Add(handleGracePeriod)
+	err = m.App.DBMethods.UpdateChannelHandle(channel.ID, channel.Handle, handle, redirectUntil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update the handle"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Handle updated successfully",
+		"handle":         handle,
+		"old_handle":     channel.Handle,
+		"redirect_until": redirectUntil,
+	})
+}
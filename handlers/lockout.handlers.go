@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihan2bd/vidverse/internal/mail"
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// the key of the failed logins of an account, it works for emails without an account too
+func accountAttemptKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// how long the next login with an email from the IP of the request has to wait
+func (m *Repo) loginWait(c *gin.Context, email string) time.Duration {
+	wait := m.App.AccountThrottle.Wait(accountAttemptKey(email))
+	if ipWait := m.App.IPThrottle.Wait(c.ClientIP()); ipWait > wait {
+		wait = ipWait
+	}
+	return wait
+}
+
+// count a failed login, the user gets an email when the account is locked
+func (m *Repo) loginFailed(c *gin.Context, email string, user *models.User) {
+	m.App.IPThrottle.Fail(c.ClientIP())
+
+	locked := m.App.AccountThrottle.Fail(accountAttemptKey(email))
+	if !locked || user == nil {
+		return
+	}
+
+	err := m.App.Mailer.SendSmtpMessage(mail.Message{
+		From:    m.App.Mailer.FromAddress,
+		To:      user.Email,
+		Subject: "Your account is temporarily locked",
+		DataMap: map[string]any{
+			"message": fmt.Sprintf("Dear %s, there were too many failed attempts to log in to your account, the last one from %s. Logging in is blocked for a while. If it was not you, please reset your password.", user.Name, c.ClientIP()),
+		},
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// forget the failed logins of an account once its user is logged in
+func (m *Repo) loginSucceeded(email string) {
+	m.App.AccountThrottle.Reset(accountAttemptKey(email))
+}
+
+// set the Retry-After header of a wait, the whole seconds are returned for the message
+func retryAfter(c *gin.Context, wait time.Duration) int {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	return seconds
+}
+
+// tell the client how long to wait before the next attempt
+func tooManyAttempts(c *gin.Context, wait time.Duration) {
+	seconds := retryAfter(c, wait)
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       fmt.Sprintf("Too many failed attempts. Please try again in %d seconds", seconds),
+		"retry_after": seconds,
+	})
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihan2bd/vidverse/helpers"
+	"github.com/raihan2bd/vidverse/internal/oidc"
+	"github.com/raihan2bd/vidverse/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// how long a user has to come back from the provider
+const oidcStateTTL = 10 * time.Minute
+
+// the cookie binding the state of a login to the browser that started it, the callback only accepts its own state
+const oidcStateCookie = "oidc_state"
+
+// HandleGetOIDCProviders list the providers users can log in with
+func (m *Repo) HandleGetOIDCProviders(c *gin.Context) {
+	providers := m.App.OIDC.Names()
+	sort.Strings(providers)
+
+	c.JSON(http.StatusOK, gin.H{"providers": providers})
+}
+
+// HandleStartOIDCLogin give the URL of the provider the user logs in at, with a PKCE challenge
+func (m *Repo) HandleStartOIDCLogin(c *gin.Context) {
+	name := c.Param("provider")
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	provider, err := m.App.OIDC.Get(ctx, name)
+	if errors.Is(err, oidc.ErrUnknownProvider) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 login provider not found"})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "The login provider is not available. Please try again later"})
+		return
+	}
+
+	state, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start the login"})
+		return
+	}
+	nonce, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start the login"})
+		return
+	}
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start the login"})
+		return
+	}
+
+	loginState := models.OIDCState{State: state, Provider: name, Verifier: verifier, Nonce: nonce, ExpiresAt: time.Now().Add(oidcStateTTL)}
+	err = m.App.DBMethods.CreateOIDCState(&loginState)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	setOIDCStateCookie(c, state, int(oidcStateTTL.Seconds()))
+
+	c.JSON(http.StatusOK, gin.H{
+		"authorization_url": provider.AuthCodeURL(state, nonce, verifier),
+		"state":             state,
+		"expires_at":        loginState.ExpiresAt,
+	})
+}
+
+// HandleOIDCCallback log in the user coming back from the provider, the account is linked to a user with the same verified email or created,
+// an account whose email was never verified is only linked with its password
+func (m *Repo) HandleOIDCCallback(c *gin.Context) {
+	name := c.Param("provider")
+
+	var payload struct {
+		Code     string `json:"code"`
+		State    string `json:"state"`
+		Password string `json:"password"`
+	}
+
+	err := c.BindJSON(&payload)
+	if err != nil || payload.Code == "" || payload.State == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code and state are required"})
+		return
+	}
+
+	// a state from another browser is a forged login
+	cookie, err := c.Cookie(oidcStateCookie)
+	setOIDCStateCookie(c, "", -1)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie), []byte(payload.State)) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The login was not started in this browser. Please try again"})
+		return
+	}
+
+	loginState, err := m.App.DBMethods.TakeOIDCState(payload.State, name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	provider, err := m.App.OIDC.Get(ctx, name)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "The login provider is not available. Please try again later"})
+		return
+	}
+
+	claims, err := provider.Exchange(ctx, payload.Code, loginState.Verifier, loginState.Nonce)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to log in with the provider. Please try again"})
+		return
+	}
+
+	user, status, err := m.oidcUser(c, name, claims, payload.Password)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	// users with two-factor authentication and admins need a second step
+	if user.TOTPEnabled || user.UserRole == "admin" {
+		m.sendTwoFactorChallenge(c, user, true)
+		return
+	}
+
+	m.sendOIDCLoginToken(c, user, false, nil)
+}
+
+// send the login token of a user who logged in with a provider, the login stands in for the password of the user for a while
+func (m *Repo) sendOIDCLoginToken(c *gin.Context, user *models.User, mfa bool, extra gin.H) {
+	reauthToken, reauthExp, err := helpers.CreateReauthToken(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	if extra == nil {
+		extra = gin.H{}
+	}
+	extra["reauth_token"] = reauthToken
+	extra["reauth_expires_at"] = reauthExp
+
+	m.sendLoginToken(c, user, mfa, extra)
+}
+
+// set or clear the state cookie, it is only sent to the login routes of the providers
+func setOIDCStateCookie(c *gin.Context, state string, maxAge int) {
+	secure := strings.HasPrefix(os.Getenv("APP_DOMAIN"), "https://")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, maxAge, "/api/v1/auth/oidc", "", secure, true)
+}
+
+// find the user of a provider account, link it by its verified email or create a new user
+func (m *Repo) oidcUser(c *gin.Context, provider string, claims *oidc.Claims, password string) (*models.User, int, error) {
+	identity, err := m.App.DBMethods.GetUserIdentity(provider, claims.Subject)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if identity != nil {
+		user, err := m.App.DBMethods.GetUserByID(identity.UserID)
+		if err != nil {
+			return nil, http.StatusUnauthorized, errors.New("the linked account is not found")
+		}
+		return user, http.StatusOK, nil
+	}
+
+	// an email the provider did not verify could belong to someone else
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, http.StatusForbidden, errors.New("the email of this account is not verified by the provider")
+	}
+
+	identity = &models.UserIdentity{Provider: provider, Subject: claims.Subject, Email: claims.Email}
+
+	user, _ := m.App.DBMethods.GetUserByEmail(claims.Email)
+	if user != nil {
+		// anyone can sign up with an email they do not own, only the one who knows the password links such an account
+		if !user.EmailVerified {
+			status, err := m.checkLinkPassword(c, user, password)
+			if err != nil {
+				return nil, status, err
+			}
+		}
+
+		identity.UserID = user.ID
+		err = m.App.DBMethods.CreateUserIdentity(identity)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		return user, http.StatusOK, nil
+	}
+
+	// the user never logs in with a password but the column can not be empty
+	randomPassword, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("something went wrong. please try again later")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(randomPassword), 12)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("something went wrong. please try again later")
+	}
+
+	newUser := models.User{
+		Name:          oidcUserName(claims),
+		Email:         claims.Email,
+		Password:      string(hash),
+		IsActive:      true,
+		EmailVerified: true,
+	}
+
+	err = m.App.DBMethods.CreateOIDCUser(&newUser, identity)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	return &newUser, http.StatusOK, nil
+}
+
+// check the password of an account before it is linked to a provider, the failures count as failed logins
+func (m *Repo) checkLinkPassword(c *gin.Context, user *models.User, password string) (int, error) {
+	if password == "" {
+		return http.StatusConflict, errors.New("an account with this email already exists. Please log in with the provider again and enter the password of the account to link them")
+	}
+
+	if wait := m.loginWait(c, user.Email); wait > 0 {
+		seconds := retryAfter(c, wait)
+		return http.StatusTooManyRequests, fmt.Errorf("too many failed attempts. Please try again in %d seconds", seconds)
+	}
+
+	if !m.checkPassword(user, password) {
+		m.loginFailed(c, user.Email, user)
+		return http.StatusUnauthorized, errors.New("the password is not correct")
+	}
+
+	m.loginSucceeded(user.Email)
+	return http.StatusOK, nil
+}
+
+// the name of a new user, the part of the email before the @ when the provider sends none
+func oidcUserName(claims *oidc.Claims) string {
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	runes := []rune(name)
+	if len(runes) > 100 {
+		name = string(runes[:100])
+	}
+
+	return name
+}
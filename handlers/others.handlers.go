@@ -5,11 +5,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/raihan2bd/vidverse/config"
+	"github.com/raihan2bd/vidverse/helpers"
 	"github.com/raihan2bd/vidverse/models"
 	validator "github.com/raihan2bd/vidverse/validators"
 )
 
-func (m *Repo) HandleGetSubscribedChannels(c *gin.Context) {
+// HandleToggleSubscription subscribe or unsubscribe the user to a channel
+func (m *Repo) HandleToggleSubscription(c *gin.Context) {
 	userID, ok := c.Get("user_id")
 	if !ok {
 		c.JSON(401, gin.H{"error": "unauthorized"})
@@ -44,9 +46,17 @@ func (m *Repo) HandleGetSubscribedChannels(c *gin.Context) {
 	c.JSON(200, gin.H{"channels": channel})
 
 	if id == 0 {
+		m.recordEvent(models.EventUnsubscribe, channel.ID, 0, userIDUint)
 		return
 	}
 
+	m.recordEvent(models.EventSubscribe, channel.ID, 0, userIDUint)
+	helpers.DispatchWebhookEvent(m.App, channel.ID, models.EventChannelSubscribed, map[string]interface{}{
+		"subscription_id": id,
+		"user_id":         userIDUint,
+		"user_name":       user.Name,
+	})
+
 	if user.ID == channel.UserID {
 		return
 	}
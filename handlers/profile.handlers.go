@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihan2bd/vidverse/helpers"
+	"github.com/raihan2bd/vidverse/internal/mail"
+	"github.com/raihan2bd/vidverse/models"
+	validator "github.com/raihan2bd/vidverse/validators"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// how long the link to verify a new email works
+const emailChangeTTL = 24 * time.Hour
+
+// check the password of a user, the user of GetUserByID comes without it
+func (m *Repo) checkPassword(user *models.User, password string) bool {
+	account, err := m.App.DBMethods.GetUserByEmail(user.Email)
+	if err != nil || account.ID != user.ID {
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(account.Password), []byte(password)) == nil
+}
+
+// check the password of a user or the re-authentication token of a fresh login with a provider,
+// the users signed up with a provider do not know their password
+func (m *Repo) confirmIdentity(user *models.User, password, reauthToken string) bool {
+	if reauthToken != "" {
+		userID, err := helpers.DecodeReauthToken(reauthToken)
+		return err == nil && userID == user.ID
+	}
+
+	return m.checkPassword(user, password)
+}
+
+// HandleUpdateMe change the name, the avatar or the email of the logged in user
+func (m *Repo) HandleUpdateMe(c *gin.Context) {
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, err := m.App.DBMethods.GetUserByID(uint(user_id.(float64)))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	name := strings.TrimSpace(c.PostForm("name"))
+	email := strings.TrimSpace(c.PostForm("email"))
+	removeAvatar := c.PostForm("remove_avatar") == "true"
+
+	avatarFile, avatarHeader, _ := c.Request.FormFile("avatar")
+	if avatarFile != nil {
+		defer avatarFile.Close()
+	}
+
+	v := validator.New()
+	if name != "" {
+		v.IsLength(name, "name", 3, 100)
+		v.IsValidFullName(name, "name")
+	}
+	if avatarHeader != nil {
+		v.IsImage(avatarHeader.Header.Get("Content-Type"), "avatar")
+		v.IsImageSize(avatarHeader.Size, 5*1024*1024, "avatar")
+	}
+
+	changeEmail := email != "" && !strings.EqualFold(email, user.Email)
+	if changeEmail {
+		v.IsEmail(email, "email", "Invalid email address")
+		v.Check(m.checkPassword(user, c.PostForm("current_password")), "current_password", "The current password is not correct")
+	}
+
+	if !v.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": v.GetErrMsg()})
+		return
+	}
+
+	if changeEmail {
+		if taken, _ := m.App.DBMethods.GetUserByEmail(email); taken != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "email address is already exist. please try another one"})
+			return
+		}
+	}
+
+	ctx := context.Background()
+	oldAvatarPublicID := user.AvatarPublicID
+
+	if avatarFile != nil {
+		// the avatar is cropped to a square before it is uploaded
+		imagePath, err := helpers.SaveToTempFile(avatarFile, "vidverse-avatar-*"+filepath.Ext(avatarHeader.Filename))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload the avatar"})
+			return
+		}
+
+		user.Avatar, user.AvatarPublicID, err = helpers.StoreAvatar(ctx, m.App.CLD, imagePath)
+		os.Remove(imagePath)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to upload the avatar. Please upload a valid png or jpeg image"})
+			return
+		}
+	} else if removeAvatar {
+		user.Avatar, user.AvatarPublicID = models.DefaultUserAvatar, ""
+	}
+
+	if name != "" {
+		user.Name = name
+	}
+
+	err = m.App.DBMethods.UpdateUserProfile(user)
+	if err != nil {
+		if user.AvatarPublicID != oldAvatarPublicID && user.AvatarPublicID != "" {
+			_ = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, user.AvatarPublicID)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if oldAvatarPublicID != "" && oldAvatarPublicID != user.AvatarPublicID {
+		err = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, oldAvatarPublicID)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	message := "Your profile has been updated"
+	var pendingEmail string
+	if changeEmail {
+		err = m.requestEmailChange(user, email)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "user": user})
+			return
+		}
+		pendingEmail = email
+		message = "Your profile has been updated. Please check the inbox of your new email to verify it"
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message, "user": user, "pending_email": pendingEmail})
+}
+
+// save the new email of a user and send the verify link to it, the old address gets a notice
+func (m *Repo) requestEmailChange(user *models.User, email string) error {
+	token, err := helpers.GenerateRandomToken(60)
+	if err != nil {
+		return errors.New("failed to change the email")
+	}
+
+	change := models.EmailChange{UserID: user.ID, NewEmail: email, Token: token, ExpiresAt: time.Now().Add(emailChangeTTL)}
+	err = m.App.DBMethods.CreateEmailChange(&change)
+	if err != nil {
+		return err
+	}
+
+	err = m.App.Mailer.SendSmtpMessage(mail.Message{
+		From:    m.App.Mailer.FromAddress,
+		To:      email,
+		Subject: "Verify your new email address",
+		DataMap: map[string]any{
+			"message": fmt.Sprintf("Dear %s, please verify your new email address by clicking on the following link %s/verify_email?token=%s. The link expires in 24 hours.", user.Name, os.Getenv("APP_DOMAIN"), token),
+		},
+	})
+	if err != nil {
+		return errors.New("failed to send the email. Please make sure your new email is correct")
+	}
+
+	err = m.App.Mailer.SendSmtpMessage(mail.Message{
+		From:    m.App.Mailer.FromAddress,
+		To:      user.Email,
+		Subject: "Your email address is being changed",
+		DataMap: map[string]any{
+			"message": fmt.Sprintf("Dear %s, a change of the email of your account to %s was requested. If you did not make this request, please change your password right away.", user.Name, email),
+		},
+	})
+	if err != nil {
+		log.Println(err)
+	}
+
+	return nil
+}
+
+// HandleVerifyEmailChange use the new email of a user once the link sent to it is opened
+func (m *Repo) HandleVerifyEmailChange(c *gin.Context) {
+	var payload struct {
+		Token string `json:"token"`
+	}
+
+	err := c.BindJSON(&payload)
+	if err != nil || payload.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	change, err := m.App.DBMethods.GetEmailChangeByToken(payload.Token)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = m.App.DBMethods.ConfirmEmailChange(change)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Your email address has been changed", "email": change.NewEmail})
+}
+
+// HandleChangePassword change the password of the logged in user, the current password or a re-authentication token is required
+func (m *Repo) HandleChangePassword(c *gin.Context) {
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, err := m.App.DBMethods.GetUserByID(uint(user_id.(float64)))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var payload struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+		ReauthToken     string `json:"reauth_token"`
+	}
+
+	err = c.BindJSON(&payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload inputs"})
+		return
+	}
+
+	v := validator.New()
+	v.IsLength(payload.NewPassword, "new_password", 6, 255)
+	v.IsValidPassword(payload.NewPassword, "new_password")
+	v.Check(payload.NewPassword != payload.CurrentPassword, "new_password", "The new password must be different from the current password")
+	if !v.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": v.GetErrMsg()})
+		return
+	}
+
+	if !m.confirmIdentity(user, payload.CurrentPassword, payload.ReauthToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "The current password is not correct"})
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(payload.NewPassword), 12)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "something went wrong. please try again later."})
+		return
+	}
+
+	user.Password = string(hash)
+	err = m.App.DBMethods.UpdateUserPassword(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Your password has been changed"})
+}
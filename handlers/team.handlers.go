@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihan2bd/vidverse/helpers"
+	"github.com/raihan2bd/vidverse/internal/mail"
+	"github.com/raihan2bd/vidverse/models"
+	validator "github.com/raihan2bd/vidverse/validators"
+)
+
+// how long an invite to a channel can be accepted
+const channelInviteTTL = 7 * 24 * time.Hour
+
+// find the logged in user with their role in the channel of the request, admins act as the owner
+func (m *Repo) findChannelTeamUser(c *gin.Context) (*models.User, *models.CustomChannelDTO, string, bool) {
+	channel, ok := m.findManagedChannel(c, models.PermManageMembers)
+	if !ok {
+		return nil, nil, "", false
+	}
+
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil, nil, "", false
+	}
+
+	user, err := m.App.DBMethods.GetUserByID(uint(user_id.(float64)))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil, nil, "", false
+	}
+
+	return user, channel, m.channelRole(c, user, channel.ID), true
+}
+
+// only the owner can give or take the manager role, managers handle the editors and analytics viewers
+func canAssignRole(actorRole, role string) bool {
+	if actorRole == models.RoleOwner {
+		return role != models.RoleOwner
+	}
+	return actorRole == models.RoleManager && (role == models.RoleEditor || role == models.RoleAnalyticsViewer)
+}
+
+// HandleGetChannelMembers list the owner and the members of a channel
+func (m *Repo) HandleGetChannelMembers(c *gin.Context) {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel id"})
+		return
+	}
+
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, err := m.App.DBMethods.GetUserByID(uint(user_id.(float64)))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// every member can see the team of the channel
+	if m.channelRole(c, user, uint(channelID)) == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied! You are not a member of this channel"})
+		return
+	}
+
+	members, err := m.App.DBMethods.GetChannelMembers(uint(channelID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get the members"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+// HandleInviteChannelMember invite a user to a channel by email
+func (m *Repo) HandleInviteChannelMember(c *gin.Context) {
+	user, channel, role, ok := m.findChannelTeamUser(c)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+
+	err := c.BindJSON(&payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invite payload"})
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(payload.Email))
+
+	v := validator.New()
+	v.IsEmail(email, "email", "Invalid email address")
+	v.IsIn(payload.Role, "role", models.ChannelMemberRoles, "Invalid role. Role must be manager, editor or analytics_viewer")
+	if !v.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": v.GetErrMsg()})
+		return
+	}
+
+	if !canAssignRole(role, payload.Role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Access denied! You are not allowed to invite a %s", payload.Role)})
+		return
+	}
+
+	// an existing member is not invited again, their role is changed with the member endpoint
+	if invited, err := m.App.DBMethods.GetUserByEmail(email); err == nil && invited.ID > 0 {
+		if memberRole, _ := m.App.DBMethods.GetChannelRole(channel.ID, invited.ID); memberRole != "" {
+			c.JSON(http.StatusConflict, gin.H{"error": "The user is already a member of this channel. Please change their role instead", "user_id": invited.ID, "role": memberRole})
+			return
+		}
+	}
+
+	token, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error. Please try again"})
+		return
+	}
+
+	invite := models.ChannelInvite{
+		ChannelID: channel.ID,
+		Email:     email,
+		Role:      payload.Role,
+		Token:     token,
+		InvitedBy: user.ID,
+		ExpiresAt: time.Now().Add(channelInviteTTL),
+	}
+
+	err = m.App.DBMethods.CreateChannelInvite(&invite)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	msg := mail.Message{
+		From:    m.App.Mailer.FromAddress,
+		To:      email,
+		Subject: fmt.Sprintf("You are invited to join %s on Vidverse", channel.Title),
+		DataMap: map[string]any{
+			"message": fmt.Sprintf("%s invited you to join the channel %s as %s. To accept the invite, please click on the following link %s/channel_invites/%s. The invite expires on %s.",
+				user.Name, channel.Title, strings.ReplaceAll(payload.Role, "_", " "), os.Getenv("APP_DOMAIN"), token, invite.ExpiresAt.Format("2006-01-02")),
+		},
+	}
+
+	err = m.App.Mailer.SendSmtpMessage(msg)
+	if err != nil {
+		if err := m.App.DBMethods.DeleteChannelInvite(channel.ID, invite.ID); err != nil {
+			log.Println(err)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send the invite. Please make sure the email is correct."})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "The invite has been sent", "invite": invite})
+}
+
+// HandleGetChannelInvites list the pending invites of a channel
+func (m *Repo) HandleGetChannelInvites(c *gin.Context) {
+	channel, ok := m.findManagedChannel(c, models.PermManageMembers)
+	if !ok {
+		return
+	}
+
+	invites, err := m.App.DBMethods.GetChannelInvites(channel.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get the invites"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invites": invites})
+}
+
+// HandleDeleteChannelInvite cancel a pending invite of a channel
+func (m *Repo) HandleDeleteChannelInvite(c *gin.Context) {
+	channel, ok := m.findManagedChannel(c, models.PermManageMembers)
+	if !ok {
+		return
+	}
+
+	inviteID, err := strconv.Atoi(c.Param("inviteID"))
+	if err != nil || inviteID <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 invite not found"})
+		return
+	}
+
+	err = m.App.DBMethods.DeleteChannelInvite(channel.ID, uint(inviteID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "The invite has been cancelled"})
+}
+
+// HandleAcceptChannelInvite join a channel with the invite sent to the email of the logged in user
+func (m *Repo) HandleAcceptChannelInvite(c *gin.Context) {
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, err := m.App.DBMethods.GetUserByID(uint(user_id.(float64)))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	invite, err := m.App.DBMethods.GetChannelInviteByToken(c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !strings.EqualFold(invite.Email, user.Email) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This invite was sent to another email address"})
+		return
+	}
+
+	// anyone can sign up with the invited email, only its owner joins the team
+	if !user.EmailVerified {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email first to accept this invite"})
+		return
+	}
+
+	role, err := m.App.DBMethods.GetChannelRole(invite.ChannelID, user.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 channel not found!"})
+		return
+	}
+	if role == models.RoleOwner {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already own this channel"})
+		return
+	}
+
+	err = m.App.DBMethods.AcceptChannelInvite(invite, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "You have joined the channel", "channel_id": invite.ChannelID, "role": invite.Role})
+}
+
+// HandleUpdateChannelMember change the role of a member of a channel
+func (m *Repo) HandleUpdateChannelMember(c *gin.Context) {
+	_, channel, role, ok := m.findChannelTeamUser(c)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil || memberID <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 member not found"})
+		return
+	}
+
+	var payload struct {
+		Role string `json:"role"`
+	}
+
+	err = c.BindJSON(&payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid member payload"})
+		return
+	}
+
+	v := validator.New()
+	v.IsIn(payload.Role, "role", models.ChannelMemberRoles, "Invalid role. Role must be manager, editor or analytics_viewer")
+	if !v.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": v.GetErrMsg()})
+		return
+	}
+
+	memberRole, err := m.App.DBMethods.GetChannelRole(channel.ID, uint(memberID))
+	if err != nil || memberRole == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 member not found"})
+		return
+	}
+
+	// the role is taken from one role and given as another, both must be allowed
+	if !canAssignRole(role, memberRole) || !canAssignRole(role, payload.Role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied! You are not allowed to change the role of this member"})
+		return
+	}
+
+	err = m.App.DBMethods.UpdateChannelMemberRole(channel.ID, uint(memberID), payload.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "The role has been updated", "user_id": memberID, "role": payload.Role})
+}
+
+// HandleDeleteChannelMember remove a member from a channel, members can also leave on their own
+func (m *Repo) HandleDeleteChannelMember(c *gin.Context) {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel id"})
+		return
+	}
+
+	memberID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil || memberID <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 member not found"})
+		return
+	}
+
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, err := m.App.DBMethods.GetUserByID(uint(user_id.(float64)))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	memberRole, err := m.App.DBMethods.GetChannelRole(uint(channelID), uint(memberID))
+	if err != nil || memberRole == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 member not found"})
+		return
+	}
+
+	if memberRole == models.RoleOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The owner can not be removed. Please transfer the channel first"})
+		return
+	}
+
+	if user.ID != uint(memberID) && !canAssignRole(m.channelRole(c, user, uint(channelID)), memberRole) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied! You are not allowed to remove this member"})
+		return
+	}
+
+	err = m.App.DBMethods.DeleteChannelMember(uint(channelID), uint(memberID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "The member has been removed"})
+}
+
+// HandleTransferChannel give the channel to one of its members, the old owner stays as a manager
+func (m *Repo) HandleTransferChannel(c *gin.Context) {
+	channel, ok := m.findManagedChannel(c, models.PermDeleteChannel)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		UserID uint `json:"user_id"`
+	}
+
+	err := c.BindJSON(&payload)
+	if err != nil || payload.UserID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+		return
+	}
+
+	if payload.UserID == channel.UserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The user already owns this channel"})
+		return
+	}
+
+	newOwner, err := m.App.DBMethods.GetUserByID(payload.UserID)
+	if err != nil || newOwner.ID == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 user not found"})
+		return
+	}
+
+	err = m.App.DBMethods.TransferChannelOwnership(channel.ID, channel.UserID, newOwner.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("The channel now belongs to %s", newOwner.Name), "owner_id": newOwner.ID})
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// HandleGetVideoThumbnails get the candidate thumbnails of a video
+func (m *Repo) HandleGetVideoThumbnails(c *gin.Context) {
+	videoID, err := strconv.Atoi(c.Param("videoID"))
+	if err != nil || videoID <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 video not found!"})
+		return
+	}
+
+	video, err := m.App.DBMethods.FindVideoByID(uint(videoID))
+	if err != nil || !m.canManageVideo(c, video) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 video not found!"})
+		return
+	}
+
+	var thumbnails []models.VideoThumbnail
+	thumbnails, err = m.App.DBMethods.GetVideoThumbnails(video.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"thumbnails": thumbnails})
+}
+
+// HandleSelectVideoThumbnail use one of the candidate thumbnails as the thumbnail of the video
+func (m *Repo) HandleSelectVideoThumbnail(c *gin.Context) {
+	videoID, err := strconv.Atoi(c.Param("videoID"))
+	if err != nil || videoID <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 video not found!"})
+		return
+	}
+
+	thumbnailID, err := strconv.Atoi(c.Param("thumbnailID"))
+	if err != nil || thumbnailID <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 thumbnail not found!"})
+		return
+	}
+
+	video, err := m.App.DBMethods.FindVideoByID(uint(videoID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 video not found!"})
+		return
+	}
+
+	if !m.canManageVideo(c, video) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied! You are not allowed to update this video"})
+		return
+	}
+
+	thumbnail, err := m.App.DBMethods.SelectVideoThumbnail(video.ID, uint(thumbnailID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Successfully updated the thumbnail", "thumbnail": thumbnail})
+}
+
+// HandleGetVideoStoryboard serve the WebVTT track that maps the seek bar to the sprite sheet tiles
+func (m *Repo) HandleGetVideoStoryboard(c *gin.Context) {
+	video, ok := m.findViewableVideo(c)
+	if !ok {
+		return
+	}
+
+	preview, err := m.App.DBMethods.GetVideoPreview(video.ID)
+	if err != nil || preview.ThumbnailTrack == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 storyboard not found!"})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/vtt; charset=utf-8", []byte(preview.ThumbnailTrack))
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandleGetTrash list the channels and videos of the user waiting in the trash
+func (m *Repo) HandleGetTrash(c *gin.Context) {
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	userID := uint(user_id.(float64))
+
+	channels, err := m.App.DBMethods.GetTrashedChannels(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get the trash"})
+		return
+	}
+
+	videos, err := m.App.DBMethods.GetTrashedVideos(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get the trash"})
+		return
+	}
+
+	for i := range channels {
+		channels[i].PurgeAt = channels[i].DeletedAt.Add(m.App.TrashRetention)
+	}
+	for i := range videos {
+		videos[i].PurgeAt = videos[i].DeletedAt.Add(m.App.TrashRetention)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"channels": channels, "videos": videos})
+}
+
+// HandleRestoreVideo take a video of the user out of the trash
+func (m *Repo) HandleRestoreVideo(c *gin.Context) {
+	videoID, err := strconv.Atoi(c.Param("videoID"))
+	if err != nil || videoID <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 video not found!"})
+		return
+	}
+
+	video, err := m.App.DBMethods.FindTrashedVideo(uint(videoID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	// the purge worker has not removed it yet but its time in the trash is over
+	if trashExpired(video.DeletedAt.Time, m.App.TrashRetention, time.Now()) {
+		c.JSON(http.StatusGone, gin.H{"error": "The video has been in the trash too long to be restored"})
+		return
+	}
+
+	channel, err := m.App.DBMethods.GetChannelByID(int(video.ChannelID))
+	if err != nil || channel.ID == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "The channel of this video is in the trash. Please restore the channel first"})
+		return
+	}
+
+	if !m.canManageVideo(c, video) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied! You are not allowed to restore this video"})
+		return
+	}
+
+	err = m.App.DBMethods.RestoreVideo(video.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	m.App.Recommender.Invalidate(video.ID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "The video has been restored", "video_id": video.ID})
+}
+
+// HandleRestoreChannel take a channel out of the trash with the videos deleted with it, only its owner and admins can
+func (m *Repo) HandleRestoreChannel(c *gin.Context) {
+	channelID, err := strconv.Atoi(c.Param("channelID"))
+	if err != nil || channelID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel id"})
+		return
+	}
+
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, err := m.App.DBMethods.GetUserByID(uint(user_id.(float64)))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	channel, err := m.App.DBMethods.FindTrashedChannel(uint(channelID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if trashExpired(channel.DeletedAt.Time, m.App.TrashRetention, time.Now()) {
+		c.JSON(http.StatusGone, gin.H{"error": "The channel has been in the trash too long to be restored"})
+		return
+	}
+
+	if !m.isAdmin(c, user) && channel.UserID != user.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied! You are not allowed to restore this channel"})
+		return
+	}
+
+	err = m.App.DBMethods.RestoreChannel(channel.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "The channel has been restored", "channel_id": channel.ID})
+}
+
+// whether an item deleted at the time is past its restore window, the purge worker removes it on its next run
+func trashExpired(deletedAt time.Time, retention time.Duration, now time.Time) bool {
+	return now.After(deletedAt.Add(retention))
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrashExpired(t *testing.T) {
+	deletedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	retention := 30 * 24 * time.Hour
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{name: "just deleted", now: deletedAt, want: false},
+		{name: "within the window", now: deletedAt.Add(29 * 24 * time.Hour), want: false},
+		{name: "last moment of the window", now: deletedAt.Add(retention), want: false},
+		{name: "window is over", now: deletedAt.Add(retention + time.Second), want: true},
+		{name: "long gone", now: deletedAt.Add(365 * 24 * time.Hour), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trashExpired(deletedAt, retention, tt.now); got != tt.want {
+				t.Errorf("trashExpired() at %v = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
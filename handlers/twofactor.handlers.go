@@ -0,0 +1,385 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihan2bd/vidverse/helpers"
+	"github.com/raihan2bd/vidverse/internal/totp"
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// send the challenge token a user trades for a login token with a two-factor code, admins without it must set it up first,
+// fromOIDC keeps in the token that the first step was a login with a provider
+func (m *Repo) sendTwoFactorChallenge(c *gin.Context, user *models.User, fromOIDC bool) {
+	challenge, exp, err := helpers.CreateChallengeToken(user.ID, fromOIDC)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	response := gin.H{
+		"two_factor_required": true,
+		"challenge_token":     challenge,
+		"expires_at":          exp,
+	}
+	if !user.TOTPEnabled {
+		response = gin.H{
+			"two_factor_setup_required": true,
+			"challenge_token":           challenge,
+			"expires_at":                exp,
+			"message":                   "Two-factor authentication is required for admins. Please set it up to log in",
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// get the user setting up two-factor authentication, from the challenge token of the login or the logged in user
+func (m *Repo) twoFactorUser(c *gin.Context, challengeToken string) (*models.User, bool, error) {
+	if challengeToken != "" {
+		userID, _, err := helpers.DecodeChallengeToken(challengeToken)
+		if err != nil {
+			return nil, false, err
+		}
+
+		user, err := m.App.DBMethods.GetUserByID(userID)
+		return user, true, err
+	}
+
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		return nil, false, errors.New("unauthorized")
+	}
+
+	user, err := m.App.DBMethods.GetUserByID(uint(user_id.(float64)))
+	return user, false, err
+}
+
+// check a code of the authenticator app of a user and mark it as used
+func (m *Repo) checkTOTPCode(user *models.User, code string) (int64, bool) {
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return 0, false
+	}
+
+	return step, m.App.DBMethods.UseTOTPStep(user.ID, step) == nil
+}
+
+// create new recovery codes, the plain codes are shown once and only their hashes are saved
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, models.RecoveryCodeCount)
+	hashes := make([]string, models.RecoveryCodeCount)
+	for i := range codes {
+		code, err := helpers.GenerateRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+		codes[i] = code
+		hashes[i] = helpers.HashToken(code)
+	}
+
+	return codes, hashes, nil
+}
+
+// HandleVerifyTwoFactor finish a login with a code of the authenticator app or a recovery code
+func (m *Repo) HandleVerifyTwoFactor(c *gin.Context) {
+	var payload struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+		RecoveryCode   string `json:"recovery_code"`
+	}
+
+	err := c.BindJSON(&payload)
+	if err != nil || (payload.Code == "" && payload.RecoveryCode == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code or recovery_code is required"})
+		return
+	}
+
+	userID, fromOIDC, err := helpers.DecodeChallengeToken(payload.ChallengeToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := m.App.DBMethods.GetUserByID(userID)
+	if err != nil || !user.TOTPEnabled {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired challenge token"})
+		return
+	}
+
+	// wrong codes count as failed logins of the account
+	if wait := m.loginWait(c, user.Email); wait > 0 {
+		tooManyAttempts(c, wait)
+		return
+	}
+
+	if payload.RecoveryCode != "" {
+		code := strings.ToLower(strings.TrimSpace(payload.RecoveryCode))
+		err = m.App.DBMethods.UseRecoveryCode(user.ID, helpers.HashToken(code))
+		if err != nil {
+			m.loginFailed(c, user.Email, user)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		m.loginSucceeded(user.Email)
+		left, _ := m.App.DBMethods.CountRecoveryCodes(user.ID)
+		m.sendChallengeLoginToken(c, user, fromOIDC, gin.H{"recovery_codes_left": left})
+		return
+	}
+
+	if _, ok := m.checkTOTPCode(user, payload.Code); !ok {
+		m.loginFailed(c, user.Email, user)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+		return
+	}
+
+	m.loginSucceeded(user.Email)
+	m.sendChallengeLoginToken(c, user, fromOIDC, nil)
+}
+
+// send the login token of a user who passed the two-factor check, a login with a provider also gets its re-authentication token
+func (m *Repo) sendChallengeLoginToken(c *gin.Context, user *models.User, fromOIDC bool, extra gin.H) {
+	if fromOIDC {
+		m.sendOIDCLoginToken(c, user, true, extra)
+		return
+	}
+
+	m.sendLoginToken(c, user, true, extra)
+}
+
+// HandleGetTwoFactor get the two-factor status of the logged in user
+func (m *Repo) HandleGetTwoFactor(c *gin.Context) {
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, err := m.App.DBMethods.GetUserByID(uint(user_id.(float64)))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var left int64
+	if user.TOTPEnabled {
+		left, _ = m.App.DBMethods.CountRecoveryCodes(user.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":             user.TOTPEnabled,
+		"required":            user.UserRole == "admin",
+		"recovery_codes_left": left,
+	})
+}
+
+// HandleSetupTwoFactor create the secret of the authenticator app, it is used once a first code is checked
+func (m *Repo) HandleSetupTwoFactor(c *gin.Context) {
+	var payload struct {
+		ChallengeToken string `json:"challenge_token"`
+		Password       string `json:"password"`
+		ReauthToken    string `json:"reauth_token"`
+	}
+
+	err := c.BindJSON(&payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload inputs"})
+		return
+	}
+
+	user, fromChallenge, err := m.twoFactorUser(c, payload.ChallengeToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// the challenge token of the login already proves the password
+	if !fromChallenge && !m.confirmIdentity(user, payload.Password, payload.ReauthToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "The password is not correct"})
+		return
+	}
+
+	if user.TOTPEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err == nil {
+		err = m.App.DBMethods.SetTOTPSecret(user.ID, secret)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set up two-factor authentication"})
+		return
+	}
+
+	issuer := os.Getenv("APP_NAME")
+	if issuer == "" {
+		issuer = "VidVerse"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      secret,
+		"otpauth_uri": totp.URI(issuer, user.Email, secret),
+		"message":     "Scan the code with your authenticator app and enter a code to enable two-factor authentication",
+	})
+}
+
+// HandleEnableTwoFactor turn on two-factor authentication with a first code and give the recovery codes
+func (m *Repo) HandleEnableTwoFactor(c *gin.Context) {
+	var payload struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+	}
+
+	err := c.BindJSON(&payload)
+	if err != nil || payload.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+
+	user, _, err := m.twoFactorUser(c, payload.ChallengeToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if user.TOTPEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	if user.TOTPSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Please set up two-factor authentication first"})
+		return
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, payload.Code, time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid two-factor code"})
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err == nil {
+		err = m.App.DBMethods.EnableTOTP(user.ID, step, hashes)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+
+	// the session is upgraded as the user just passed the two-factor check
+	user.TOTPEnabled = true
+	_, fromOIDC, _ := helpers.DecodeChallengeToken(payload.ChallengeToken)
+	m.sendChallengeLoginToken(c, user, fromOIDC, gin.H{
+		"message":        "Two-factor authentication is enabled. Please keep your recovery codes in a safe place, they are only shown once",
+		"recovery_codes": codes,
+	})
+}
+
+// HandleDisableTwoFactor turn off two-factor authentication, admins can not
+func (m *Repo) HandleDisableTwoFactor(c *gin.Context) {
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, err := m.App.DBMethods.GetUserByID(uint(user_id.(float64)))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var payload struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+
+	err = c.BindJSON(&payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload inputs"})
+		return
+	}
+
+	if !user.TOTPEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+
+	if user.UserRole == "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Two-factor authentication is required for admins"})
+		return
+	}
+
+	if !m.checkPassword(user, payload.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "The password is not correct"})
+		return
+	}
+
+	if _, ok := m.checkTOTPCode(user, payload.Code); !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+		return
+	}
+
+	err = m.App.DBMethods.DisableTOTP(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication is disabled"})
+}
+
+// HandleRegenerateRecoveryCodes replace the recovery codes of the logged in user, the old ones stop working
+func (m *Repo) HandleRegenerateRecoveryCodes(c *gin.Context) {
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, err := m.App.DBMethods.GetUserByID(uint(user_id.(float64)))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var payload struct {
+		Code string `json:"code"`
+	}
+
+	err = c.BindJSON(&payload)
+	if err != nil || payload.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+
+	if !user.TOTPEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+
+	if _, ok := m.checkTOTPCode(user, payload.Code); !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err == nil {
+		err = m.App.DBMethods.ReplaceRecoveryCodes(user.ID, hashes)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create the recovery codes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
@@ -2,17 +2,22 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/raihan2bd/vidverse/config"
 	"github.com/raihan2bd/vidverse/helpers"
 	"github.com/raihan2bd/vidverse/initializers"
+	"github.com/raihan2bd/vidverse/internal/media"
+	"github.com/raihan2bd/vidverse/internal/recommend"
 	"github.com/raihan2bd/vidverse/models"
 	validator "github.com/raihan2bd/vidverse/validators"
 )
@@ -62,10 +67,6 @@ func (m *Repo) HandleGetAllVideos(c *gin.Context) {
 	})
 }
 
-func (m *Repo) generateThumbURL(publicID string) string {
-	return fmt.Sprintf("https://res.cloudinary.com/%s/video/upload/%s.jpeg", initializers.CLD.Config.Cloud.CloudName, publicID)
-}
-
 func (m *Repo) HandleCreateVideo(c *gin.Context) {
 	// authorization
 	user_id, ok := c.Get("user_id")
@@ -86,16 +87,6 @@ func (m *Repo) HandleCreateVideo(c *gin.Context) {
 		return
 	}
 
-	// check the user role
-	if user.UserRole != "admin" {
-		if user.UserRole != "author" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied! You are not allowed to upload video",
-			})
-			return
-		}
-	}
-
 	videoFile, fileInfo, err := c.Request.FormFile("video")
 	if err != nil {
 		c.IndentedJSON(400, gin.H{"error": "File is required."})
@@ -106,7 +97,6 @@ func (m *Repo) HandleCreateVideo(c *gin.Context) {
 		return
 	}
 
-	var thumbSecureURL, thumbPublicID string
 	thumbFile, thumbFileInfo, err := c.Request.FormFile("thumb")
 	if err != nil {
 	} else if thumbFileInfo != nil {
@@ -115,8 +105,7 @@ func (m *Repo) HandleCreateVideo(c *gin.Context) {
 
 	validator := validator.New()
 
-	// validate video
-	validator.IsVideo(fileInfo.Header.Get("Content-Type"), "video")
+	// validate video size, the content is checked with ffprobe once it is on disk
 	validator.IsVideoSize(fileInfo.Size, 100*1024*1024, "video")
 
 	// Todo: add image upload system later
@@ -125,7 +114,7 @@ func (m *Repo) HandleCreateVideo(c *gin.Context) {
 	description := c.PostForm("description")
 	channel_id := c.PostForm("channel_id")
 
-	channelID, err := strconv.Atoi(channel_id)
+	channelID, err := m.resolveChannelID(channel_id)
 	if err != nil {
 		c.IndentedJSON(http.StatusBadRequest, gin.H{
 			"error": "Invalid channel id",
@@ -139,6 +128,20 @@ func (m *Repo) HandleCreateVideo(c *gin.Context) {
 	validator.IsLength(description, "description", 25, 500)
 	validator.Required(channel_id, "channel_id", "channel_id is required")
 
+	tags := helpers.ParseTags(c.PostForm("tags"))
+	category := c.DefaultPostForm("category", "other")
+	validator.IsTags(tags, "tags", 10)
+	validator.IsIn(category, "category", models.VideoCategories, "Invalid category. Please choose one of the available categories")
+
+	visibility := c.DefaultPostForm("visibility", models.VisibilityPublic)
+	validator.IsIn(visibility, "visibility", models.VideoVisibilities, "Invalid visibility. Visibility must be public, unlisted, private or draft")
+
+	publishAt := parsePublishAt(c.PostForm("publish_at"), validator)
+	if publishAt != nil && visibility == models.VisibilityPublic {
+		// scheduled videos stay private until their publish time
+		visibility = models.VisibilityPrivate
+	}
+
 	if thumbFileInfo != nil && thumbFile != nil {
 		validator.IsImage(thumbFileInfo.Header.Get("Content-Type"), "thumb")
 		validator.IsImageSize(thumbFileInfo.Size, 5*1024*1024, "thumb")
@@ -161,20 +164,17 @@ func (m *Repo) HandleCreateVideo(c *gin.Context) {
 		return
 	}
 
-	// check if the channel user is the same or not
-	if channel.UserID != userID {
-		if user.UserRole != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied! You are not allowed to upload video to this channel",
-			})
-			return
-		}
+	// the owner and the members who manage the videos can upload to the channel
+	if !models.RoleCan(m.channelRole(c, user, channel.ID), models.PermManageVideos) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied! You are not allowed to upload video to this channel",
+		})
+		return
 	}
 
-	// upload video to cloudinary
-	ctx := context.Background()
-	var secureURL, videoPublicID string
-	secureURL, videoPublicID, err = helpers.UploadVideoToCloudinary(ctx, m.App.CLD, videoFile)
+	// keep the files on disk, they are uploaded in the background after the response
+	var videoPath, thumbPath string
+	videoPath, err = helpers.SaveToTempFile(videoFile, "vidverse-video-*"+filepath.Ext(fileInfo.Filename))
 	if err != nil {
 		c.IndentedJSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to upload the video",
@@ -182,25 +182,30 @@ func (m *Repo) HandleCreateVideo(c *gin.Context) {
 		return
 	}
 
+	metadata, err := m.probeVideo(c, videoPath)
+	if err != nil {
+		_ = os.Remove(videoPath)
+		return
+	}
+
+	chapters, chaptersWarning := parseChapters(description, metadata.Duration)
+
 	if thumbFileInfo != nil && thumbFile != nil {
-		// upload thumb to cloudinary
-		thumbSecureURL, thumbPublicID, err = helpers.UploadImageToCloudinary(ctx, m.App.CLD, thumbFile, "vidverse/uploads/thumbs")
+		thumbPath, err = helpers.SaveToTempFile(thumbFile, "vidverse-thumb-*"+filepath.Ext(thumbFileInfo.Filename))
 		if err != nil {
-			thumbSecureURL = m.generateThumbURL(videoPublicID)
+			thumbPath = ""
 		}
-	} else {
-		// generate thumb url
-		thumbSecureURL = m.generateThumbURL(videoPublicID)
 	}
 
-	video := models.Video{Title: title, Description: description, PublicID: videoPublicID, SecureURL: secureURL, ChannelID: channel.ID, Thumb: thumbSecureURL, ThumbPublicID: thumbPublicID}
+	video := models.Video{Title: title, Description: description, ChannelID: channel.ID, Category: category, Chapters: chapters, Visibility: visibility, PublishAt: publishAt, Status: models.StatusUploading}
+	setVideoMetadata(&video, metadata)
 
-	videoID, err := m.App.DBMethods.CreateVideo(&video)
+	videoID, err := m.App.DBMethods.CreateVideo(&video, tags)
 	if err != nil {
-		// delete thumbnail from cloudinary
-		_ = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, thumbPublicID)
-		// delete video from cloudinary
-		_ = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, videoPublicID)
+		_ = os.Remove(videoPath)
+		if thumbPath != "" {
+			_ = os.Remove(thumbPath)
+		}
 
 		c.IndentedJSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create the video",
@@ -208,10 +213,77 @@ func (m *Repo) HandleCreateVideo(c *gin.Context) {
 		return
 	}
 
-	c.IndentedJSON(http.StatusCreated, gin.H{
-		"message":  "Successfully created the video",
-		"video_id": videoID,
+	c.IndentedJSON(http.StatusAccepted, gin.H{
+		"message":          "Successfully received the video. It will be available once it is processed",
+		"video_id":         videoID,
+		"status":           video.Status,
+		"chapters_warning": chaptersWarning,
 	})
+
+	go func() {
+		m.App.VideoJobChan <- &config.VideoJob{VideoID: videoID, UserID: userID, VideoPath: videoPath, ThumbPath: thumbPath}
+	}()
+}
+
+// read the metadata of the uploaded video and respond with an error if it is not a real video
+func (m *Repo) probeVideo(c *gin.Context, path string) (*media.Metadata, error) {
+	metadata, err := media.Probe(c.Request.Context(), path)
+	if errors.Is(err, media.ErrNotVideo) {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid video type. Please upload a valid video",
+		})
+		return nil, err
+	}
+
+	if err != nil {
+		log.Println(err)
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to read the video",
+		})
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+func setVideoMetadata(video *models.Video, metadata *media.Metadata) {
+	video.Duration = metadata.Duration
+	video.Width = metadata.Width
+	video.Height = metadata.Height
+	video.Codec = metadata.Codec
+	video.Bitrate = metadata.Bitrate
+	video.FileSize = metadata.Size
+}
+
+// read the chapters from the timestamps of the description, a description whose timestamps
+// are not valid chapters is still a valid description so it only has no chapters
+func parseChapters(description string, duration float64) ([]models.Chapter, string) {
+	marks, err := media.ParseChapters(description, duration)
+	if err != nil {
+		return nil, fmt.Sprintf("The timestamps of the description are not shown as chapters. %s", err.Error())
+	}
+
+	chapters := make([]models.Chapter, 0, len(marks))
+	for i, mark := range marks {
+		chapters = append(chapters, models.Chapter{Position: i + 1, Title: mark.Title, StartTime: mark.Start, EndTime: mark.End})
+	}
+
+	return chapters, ""
+}
+
+// parse the publish time of a scheduled video, it must be in the future
+func parsePublishAt(raw string, v *validator.Validator) *time.Time {
+	if raw == "" {
+		return nil
+	}
+
+	publishAt, err := time.Parse(time.RFC3339, raw)
+	if err != nil || !publishAt.After(time.Now()) {
+		v.AddError("publish_at", "publish_at must be a future date in RFC3339 format")
+		return nil
+	}
+
+	return &publishAt
 }
 
 // handle update video
@@ -235,16 +307,6 @@ func (m *Repo) HandleUpdateVideo(c *gin.Context) {
 		return
 	}
 
-	// check the user role
-	if user.UserRole != "admin" {
-		if user.UserRole != "author" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied! You are not allowed to upload video",
-			})
-			return
-		}
-	}
-
 	videoID, err := strconv.Atoi(c.Params.ByName("videoID"))
 	if err != nil {
 		c.IndentedJSON(http.StatusNotFound, gin.H{
@@ -263,6 +325,21 @@ func (m *Repo) HandleUpdateVideo(c *gin.Context) {
 		return
 	}
 
+	// check the role of the user in the channel of the video
+	if !models.RoleCan(m.channelRole(c, user, video.ChannelID), models.PermManageVideos) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied! You are not allowed to update this video",
+		})
+		return
+	}
+
+	if video.Status == models.StatusUploading || video.Status == models.StatusProcessing {
+		c.IndentedJSON(http.StatusConflict, gin.H{
+			"error": "The video is still being processed. Please try again later",
+		})
+		return
+	}
+
 	videoFile, fileInfo, _ := c.Request.FormFile("video")
 	if fileInfo != nil && videoFile != nil {
 		defer videoFile.Close()
@@ -273,7 +350,7 @@ func (m *Repo) HandleUpdateVideo(c *gin.Context) {
 		defer thumbFile.Close()
 	}
 
-	var thumbUrl, thumbPublicID, videoUrl, videoPublicID string
+	var thumbUrl, thumbPublicID string
 
 	// validate form data
 	validator := validator.New()
@@ -291,13 +368,32 @@ func (m *Repo) HandleUpdateVideo(c *gin.Context) {
 		description = video.Description
 	}
 
+	rawTags, hasTags := c.GetPostForm("tags")
+	tags := helpers.ParseTags(rawTags)
+	if hasTags {
+		validator.IsTags(tags, "tags", 10)
+	}
+
+	category := c.PostForm("category")
+	if category != "" {
+		validator.IsIn(category, "category", models.VideoCategories, "Invalid category. Please choose one of the available categories")
+	} else {
+		category = video.Category
+	}
+
+	visibility := c.PostForm("visibility")
+	if visibility != "" {
+		validator.IsIn(visibility, "visibility", models.VideoVisibilities, "Invalid visibility. Visibility must be public, unlisted, private or draft")
+	}
+
+	publishAt := parsePublishAt(c.PostForm("publish_at"), validator)
+
 	if thumbFileInfo != nil && thumbFile != nil {
 		validator.IsImage(thumbFileInfo.Header.Get("Content-Type"), "thumb")
 		validator.IsImageSize(thumbFileInfo.Size, 5*1024*1024, "thumb")
 	}
 
 	if videoFile != nil && fileInfo != nil {
-		validator.IsVideo(fileInfo.Header.Get("Content-Type"), "video")
 		validator.IsVideoSize(fileInfo.Size, 100*1024*1024, "video")
 	}
 
@@ -308,54 +404,123 @@ func (m *Repo) HandleUpdateVideo(c *gin.Context) {
 		return
 	}
 
-	// upload video to cloudinary if video file is available
+	// a new video file is processed in the background like a new upload
 	ctx := context.Background()
+	var (
+		metadata            *media.Metadata
+		videoPath, jobThumb string
+		queued              bool
+	)
+	defer func() {
+		// the worker removes the files of a queued video once it is processed
+		if !queued {
+			if videoPath != "" {
+				_ = os.Remove(videoPath)
+			}
+			if jobThumb != "" {
+				_ = os.Remove(jobThumb)
+			}
+		}
+	}()
+
 	if videoFile != nil && fileInfo != nil {
-		videoUrl, videoPublicID, err = helpers.UploadVideoToCloudinary(ctx, m.App.CLD, videoFile)
+		videoPath, err = helpers.SaveToTempFile(videoFile, "vidverse-video-*"+filepath.Ext(fileInfo.Filename))
 		if err != nil {
+			videoPath = ""
 			c.IndentedJSON(http.StatusInternalServerError, gin.H{
 				"error": "Failed to upload the video",
 			})
 			return
 		}
-	} else {
-		videoUrl = video.SecureURL
-		videoPublicID = video.PublicID
+
+		metadata, err = m.probeVideo(c, videoPath)
+		if err != nil {
+			return
+		}
 	}
 
-	// upload thumb to cloudinary if thumb file is available
+	// a new description or a new video length can change the chapters
+	var (
+		chapters        []models.Chapter
+		chaptersWarning string
+	)
+	updateChapters := c.PostForm("description") != "" || metadata != nil
+	if updateChapters {
+		duration := video.Duration
+		if metadata != nil {
+			duration = metadata.Duration
+		}
+
+		chapters, chaptersWarning = parseChapters(description, duration)
+	}
+
+	// render the uploaded thumb in every size if thumb file is available,
+	// with a new video file the worker stores it among the new candidates
+	var thumbnail *models.VideoThumbnail
+	thumbUrl, thumbPublicID = video.Thumb, video.ThumbPublicID
 	if thumbFileInfo != nil && thumbFile != nil {
-		thumbUrl, thumbPublicID, err = helpers.UploadImageToCloudinary(ctx, m.App.CLD, thumbFile, "vidverse/uploads/thumbs")
+		var thumbPath string
+		thumbPath, err = helpers.SaveToTempFile(thumbFile, "vidverse-thumb-*"+filepath.Ext(thumbFileInfo.Filename))
 		if err != nil {
-			if thumbUrl == "" && thumbPublicID == "" && videoPublicID != "" && videoUrl != "" {
-				thumbUrl = m.generateThumbURL(videoPublicID)
+			log.Println(err)
+		} else if metadata != nil {
+			jobThumb = thumbPath
+		} else {
+			thumbnail, err = helpers.StoreThumbnail(ctx, m.App.CLD, thumbPath)
+			os.Remove(thumbPath)
+			if err != nil {
+				log.Println(err)
+				thumbnail = nil
 			} else {
-				thumbUrl = video.Thumb
+				thumbnail.VideoID = video.ID
+				thumbnail.IsCustom = true
+				thumbUrl, thumbPublicID = thumbnail.Medium, ""
+				video.ThumbSmall, video.ThumbLarge = thumbnail.Small, thumbnail.Large
 			}
 		}
-	} else {
-		thumbUrl = video.Thumb
 	}
 
-	var oldVideoPublicID, oldThumbPublicID string = video.PublicID, video.ThumbPublicID
+	oldThumbPublicID := video.ThumbPublicID
+
+	// the subscribers of a live video are not notified again when its file is replaced
+	wasPublished := video.Status == models.StatusReady && video.Visibility == models.VisibilityPublic
 
 	// update video
 	video.Title = title
 	video.Description = description
-	video.SecureURL = videoUrl
 	video.Thumb = thumbUrl
 	video.ThumbPublicID = thumbPublicID
-	video.PublicID = videoPublicID
+	video.Category = category
+	if metadata != nil {
+		setVideoMetadata(video, metadata)
+		// a video that failed before starts over with the new file
+		video.Status = models.StatusUploading
+		video.StatusMessage = ""
+	}
+
+	wasPublic := video.Visibility == models.VisibilityPublic
+	if visibility != "" {
+		video.Visibility = visibility
+		// publishing or hiding the video manually cancels the schedule
+		video.PublishAt = nil
+	}
+	if publishAt != nil {
+		video.PublishAt = publishAt
+		if video.Visibility == models.VisibilityPublic {
+			video.Visibility = models.VisibilityPrivate
+		}
+	}
 
-	err = m.App.DBMethods.UpdateVideo(video)
+	// the tags are written with the video so they are only saved once the uploads succeeded
+	if hasTags {
+		err = m.App.DBMethods.UpdateVideoWithTags(video, tags)
+	} else {
+		err = m.App.DBMethods.UpdateVideo(video)
+	}
 	if err != nil {
 		// delete thumbnail from cloudinary
-		if thumbPublicID != video.ThumbPublicID {
-			_ = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, thumbPublicID)
-		}
-
-		if videoPublicID != video.PublicID {
-			_ = helpers.DeleteVideoFromCloudinary(ctx, m.App.CLD, videoPublicID)
+		if thumbnail != nil {
+			helpers.DeleteThumbnailFromCloudinary(ctx, m.App.CLD, thumbnail)
 		}
 
 		c.IndentedJSON(http.StatusInternalServerError, gin.H{
@@ -364,20 +529,52 @@ func (m *Repo) HandleUpdateVideo(c *gin.Context) {
 		return
 	}
 
+	if metadata != nil {
+		queued = true
+		go func() {
+			m.App.VideoJobChan <- &config.VideoJob{VideoID: video.ID, UserID: userID, VideoPath: videoPath, ThumbPath: jobThumb, WasPublished: wasPublished}
+		}()
+	}
+
+	if thumbnail != nil {
+		thumbnails := []models.VideoThumbnail{*thumbnail}
+		err = m.App.DBMethods.CreateVideoThumbnails(thumbnails)
+		if err == nil {
+			_, err = m.App.DBMethods.SelectVideoThumbnail(video.ID, thumbnails[0].ID)
+		}
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	if updateChapters {
+		err = m.App.DBMethods.ReplaceVideoChapters(video.ID, chapters)
+		if err != nil {
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{
+				"error": "The video is updated but its chapters could not be saved. Please try again",
+			})
+			return
+		}
+	}
+
 	c.IndentedJSON(http.StatusOK, gin.H{
-		"message":  "Successfully updated the video",
-		"video_id": video.ID,
+		"message":          "Successfully updated the video",
+		"video_id":         video.ID,
+		"status":           video.Status,
+		"chapters_warning": chaptersWarning,
 	})
 
+	// the title or description may have changed, rank the related videos again
+	m.App.Recommender.Invalidate(video.ID)
+
+	if !wasPublic && video.Visibility == models.VisibilityPublic && video.Status == models.StatusReady {
+		go helpers.NotifySubscribers(m.App, video.ID)
+	}
+
 	// delete old thumbnail from cloudinary
 	if oldThumbPublicID != "" && (thumbPublicID != oldThumbPublicID) {
 		_ = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, oldThumbPublicID)
 	}
-
-	if oldVideoPublicID != "" && (videoPublicID != oldVideoPublicID) {
-		_ = helpers.DeleteVideoFromCloudinary(ctx, m.App.CLD, oldVideoPublicID)
-	}
-
 }
 
 func (m *Repo) HandleGetSingleVideo(c *gin.Context) {
@@ -405,6 +602,24 @@ func (m *Repo) HandleGetSingleVideo(c *gin.Context) {
 		return
 	}
 
+	// private videos, drafts and videos still being processed are only visible to the channel team and admins
+	if video.Visibility == models.VisibilityPrivate || video.Visibility == models.VisibilityDraft || video.Status != models.StatusReady {
+		if !m.canManageVideo(c, video) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "404 video not found!",
+			})
+			return
+		}
+	}
+
+	// only the views of the videos the viewer is allowed to see are counted
+	err = m.App.DBMethods.IncrementVideoViews(video.ID)
+	if err != nil {
+		log.Println(err)
+	} else {
+		video.Views++
+	}
+
 	var channel = &models.ChannelPayload{
 		ID:            video.Channel.ID,
 		Title:         video.Channel.Title,
@@ -414,14 +629,22 @@ func (m *Repo) HandleGetSingleVideo(c *gin.Context) {
 		Subscriptions: video.Channel.Subscriptions,
 	}
 	// check the user is logged in or not
-	var isLiked bool
+	var (
+		isLiked  bool
+		viewerID uint
+	)
 	userID, ok := c.Get("user_id")
 	if !ok {
 		channel.IsSubscribed = false
 		isLiked = false
 	} else {
 		userIDUint := uint(userID.(float64))
+		viewerID = userIDUint
 		channel.IsSubscribed = m.App.DBMethods.IsSubscribed(userIDUint, video.Channel.ID)
+		err = m.App.DBMethods.AddToWatchHistory(userIDUint, video.ID)
+		if err != nil {
+			log.Println(err)
+		}
 		_, err := m.App.DBMethods.GetLikeByVideoIDAndUserID(video.ID, userIDUint)
 		if err != nil {
 			isLiked = false
@@ -430,6 +653,16 @@ func (m *Repo) HandleGetSingleVideo(c *gin.Context) {
 		}
 	}
 
+	m.recordEvent(models.EventView, video.ChannelID, video.ID, viewerID)
+
+	// the preview is generated after the video is ready so it may not exist yet
+	var previewURL, spriteURL, thumbnailTrack string
+	if preview, err := m.App.DBMethods.GetVideoPreview(video.ID); err == nil {
+		previewURL = preview.PreviewURL
+		spriteURL = preview.SpriteURL
+		thumbnailTrack = fmt.Sprintf("/api/v1/videos/%d/storyboard.vtt", video.ID)
+	}
+
 	c.IndentedJSON(http.StatusOK, gin.H{
 		"title":       video.Title,
 		"description": video.Description,
@@ -439,31 +672,132 @@ func (m *Repo) HandleGetSingleVideo(c *gin.Context) {
 		"likes":       len(video.Likes),
 		"views":       video.Views,
 		"thumb":       video.Thumb,
+		"thumb_small": video.ThumbSmall,
+		"thumb_large": video.ThumbLarge,
 		"is_liked":    isLiked,
+		"category":    video.Category,
+		"tags":        video.Tags,
+		"visibility":  video.Visibility,
+		"publish_at":  video.PublishAt,
+		"status":      video.Status,
+		"duration":    video.Duration,
+		"width":       video.Width,
+		"height":      video.Height,
+		"chapters":    video.Chapters,
+		"watermark":   gin.H{"url": video.Channel.Watermark, "position": video.Channel.WatermarkPosition},
+
+		"preview_url":     previewURL,
+		"sprite_url":      spriteURL,
+		"thumbnail_track": thumbnailTrack,
 	})
 
 }
 
-// Get related videos
-func (m *Repo) HandleGetRelatedVideos(c *gin.Context) {
-	id, err := strconv.Atoi(c.Params.ByName("channelID"))
-	if err != nil {
-		c.IndentedJSON(http.StatusBadGateway, gin.H{
-			"error": "Invalid ID",
+// Get the processing status of a video
+func (m *Repo) HandleGetVideoStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Params.ByName("videoID"))
+	if err != nil || id <= 0 {
+		c.IndentedJSON(http.StatusNotFound, gin.H{
+			"error": "404 video not found!",
 		})
 		return
 	}
 
-	var videos []models.VideoDTO
-	videos, _, err = m.App.DBMethods.GetVideosByChannelID(id, 1, 24)
-
-	if err != nil {
+	video, err := m.App.DBMethods.FindVideoByID(uint(id))
+	if err != nil || !m.canManageVideo(c, video) {
 		c.IndentedJSON(http.StatusNotFound, gin.H{
 			"error": "404 video not found!",
 		})
 		return
 	}
 
+	c.IndentedJSON(http.StatusOK, gin.H{
+		"video_id":       video.ID,
+		"status":         video.Status,
+		"status_message": video.StatusMessage,
+	})
+}
+
+// check the logged in user can manage the videos of the channel of the video or is an admin
+func (m *Repo) canManageVideo(c *gin.Context, video *models.Video) bool {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		return false
+	}
+
+	user, err := m.App.DBMethods.GetUserByID(uint(userID.(float64)))
+	if err != nil {
+		return false
+	}
+
+	return models.RoleCan(m.channelRole(c, user, video.ChannelID), models.PermManageVideos)
+}
+
+// find the video of the request, hiding private, draft and unfinished videos from anyone but the channel team and admins
+func (m *Repo) findViewableVideo(c *gin.Context) (*models.Video, bool) {
+	videoID, err := strconv.Atoi(c.Param("videoID"))
+	if err != nil || videoID <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 video not found!"})
+		return nil, false
+	}
+
+	video, err := m.App.DBMethods.FindVideoByID(uint(videoID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 video not found!"})
+		return nil, false
+	}
+
+	if video.Visibility == models.VisibilityPrivate || video.Visibility == models.VisibilityDraft || video.Status != models.StatusReady {
+		if !m.canManageVideo(c, video) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "404 video not found!"})
+			return nil, false
+		}
+	}
+
+	return video, true
+}
+
+// limits of the related videos lookup
+const (
+	relatedKeywords   = 12
+	relatedCandidates = 500
+	relatedLimit      = 24
+)
+
+// Get related videos of a video
+func (m *Repo) HandleGetRelatedVideos(c *gin.Context) {
+	// a private or unfinished video only shows its related videos to its team
+	video, ok := m.findViewableVideo(c)
+	if !ok {
+		return
+	}
+
+	var err error
+	videoIDs, ok := m.App.Recommender.Get(video.ID)
+	if !ok {
+		var candidates []models.RelatedCandidate
+		keywords := recommend.Keywords(video.Title+" "+video.Description, relatedKeywords)
+		candidates, err = m.App.DBMethods.GetRelatedVideoCandidates(video, keywords, relatedCandidates)
+		if err != nil {
+			c.IndentedJSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to get related videos",
+			})
+			return
+		}
+
+		videoIDs = m.App.Recommender.Rank(video, candidates, relatedLimit)
+		m.App.Recommender.Set(video.ID, videoIDs)
+	}
+
+	var videos []models.VideoDTO
+	videos, err = m.App.DBMethods.GetVideosByIDs(videoIDs)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get related videos",
+		})
+		return
+	}
+
 	if len(videos) == 0 {
 		videos, _, err = m.App.DBMethods.GetAllVideos(1, 24, "")
 		if err != nil {
@@ -472,6 +806,15 @@ func (m *Repo) HandleGetRelatedVideos(c *gin.Context) {
 			})
 			return
 		}
+
+		// never recommend the video itself
+		related := videos[:0]
+		for _, v := range videos {
+			if v.ID != video.ID {
+				related = append(related, v)
+			}
+		}
+		videos = related
 	}
 
 	c.IndentedJSON(http.StatusOK, gin.H{
@@ -666,18 +1009,16 @@ func (m *Repo) HandleDeleteVideo(c *gin.Context) {
 		return
 	}
 
-	// check the user role
-	if user.UserRole != "admin" {
-		if user.UserRole != "author" || video.Channel.UserID != user.ID {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied! You are not allowed to delete video",
-			})
-			return
-		}
+	// check the role of the user in the channel of the video
+	if !models.RoleCan(m.channelRole(c, user, video.ChannelID), models.PermManageVideos) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Access denied! You are not allowed to delete video",
+		})
+		return
 	}
 
-	// delete video from database
-	err = m.App.DBMethods.DeleteVideoModel(video)
+	// move the video to the trash, it is deleted for good after the retention
+	err = m.App.DBMethods.TrashVideo(video)
 	if err != nil {
 		c.IndentedJSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to delete the video",
@@ -686,9 +1027,12 @@ func (m *Repo) HandleDeleteVideo(c *gin.Context) {
 	}
 
 	c.IndentedJSON(http.StatusOK, gin.H{
-		"message": "Successfully deleted the video",
+		"message":  "The video has been moved to the trash",
+		"purge_at": time.Now().Add(m.App.TrashRetention),
 	})
 
+	m.App.Recommender.Invalidate(video.ID)
+
 }
 
 // func (m *Repo) UploadVideo(c *gin.Context) {
@@ -868,6 +1212,14 @@ func (m *Repo) HandleVideoLike(c *gin.Context) {
 			"like_id": id,
 		})
 
+		m.recordEvent(models.EventLike, video.ChannelID, video.ID, user.ID)
+		helpers.DispatchWebhookEvent(m.App, video.ChannelID, models.EventVideoLiked, map[string]interface{}{
+			"like_id":   id,
+			"video_id":  video.ID,
+			"user_id":   user.ID,
+			"user_name": user.Name,
+		})
+
 		if video.Channel.UserID == user.ID {
 			return
 		}
@@ -903,4 +1255,6 @@ func (m *Repo) HandleVideoLike(c *gin.Context) {
 		"message": "Successfully unliked the video",
 	})
 
+	m.recordEvent(models.EventUnlike, video.ChannelID, video.ID, user.ID)
+
 }
@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihan2bd/vidverse/helpers"
+	"github.com/raihan2bd/vidverse/internal/webhook"
+	"github.com/raihan2bd/vidverse/models"
+	validator "github.com/raihan2bd/vidverse/validators"
+)
+
+// how many webhooks a channel can have
+const maxChannelWebhooks = 10
+
+// check the URL and the events of a webhook, the events are returned once each in a fixed order
+func validateWebhook(v *validator.Validator, rawURL string, events []string) string {
+	v.IsLength(rawURL, "url", 1, 500)
+	parsed, err := url.Parse(rawURL)
+	validURL := err == nil && (parsed.Scheme == "https" || parsed.Scheme == "http") && parsed.Host != ""
+	v.Check(validURL, "url", "The url must be a valid http or https URL")
+	if validURL {
+		v.Check(webhook.PublicHost(parsed.Hostname()), "url", "The url must not point to the local machine or a private network")
+	}
+
+	v.Check(len(events) > 0, "events", "At least one event is required")
+	for _, event := range events {
+		v.IsIn(event, "events", models.WebhookEvents, "Events must be in "+strings.Join(models.WebhookEvents, ", "))
+	}
+
+	var ordered []string
+	for _, event := range models.WebhookEvents {
+		for _, requested := range events {
+			if requested == event {
+				ordered = append(ordered, event)
+				break
+			}
+		}
+	}
+
+	return strings.Join(ordered, ",")
+}
+
+// find a webhook of the channel of the request
+func (m *Repo) findChannelWebhook(c *gin.Context) (*models.Webhook, bool) {
+	channel, ok := m.findManagedChannel(c, models.PermManageHooks)
+	if !ok {
+		return nil, false
+	}
+
+	webhookID, err := strconv.Atoi(c.Param("webhookID"))
+	if err != nil || webhookID <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "404 webhook not found"})
+		return nil, false
+	}
+
+	hook, err := m.App.DBMethods.GetWebhook(channel.ID, uint(webhookID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	return hook, true
+}
+
+// HandleGetWebhooks list the webhooks of a channel
+func (m *Repo) HandleGetWebhooks(c *gin.Context) {
+	channel, ok := m.findManagedChannel(c, models.PermManageHooks)
+	if !ok {
+		return
+	}
+
+	webhooks, err := m.App.DBMethods.GetWebhooks(channel.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks, "events": models.WebhookEvents})
+}
+
+// HandleCreateWebhook register an endpoint for events of a channel, the signing secret is only shown in this response
+func (m *Repo) HandleCreateWebhook(c *gin.Context) {
+	channel, ok := m.findManagedChannel(c, models.PermManageHooks)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+	}
+
+	err := c.BindJSON(&payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload inputs"})
+		return
+	}
+
+	payload.URL = strings.TrimSpace(payload.URL)
+
+	v := validator.New()
+	events := validateWebhook(v, payload.URL, payload.Events)
+	if !v.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": v.GetErrMsg()})
+		return
+	}
+
+	webhooks, err := m.App.DBMethods.GetWebhooks(channel.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(webhooks) >= maxChannelWebhooks {
+		c.JSON(http.StatusConflict, gin.H{"error": "A channel can have up to 10 webhooks. Please delete one first"})
+		return
+	}
+
+	secret, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create the webhook"})
+		return
+	}
+
+	user_id, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	hook := models.Webhook{
+		ChannelID: channel.ID,
+		UserID:    uint(user_id.(float64)),
+		URL:       payload.URL,
+		Secret:    "whsec_" + strings.TrimRight(secret, "="),
+		Events:    events,
+		Active:    true,
+	}
+
+	err = m.App.DBMethods.CreateWebhook(&hook)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Please copy the signing secret now, it will not be shown again",
+		"secret":  hook.Secret,
+		"webhook": hook,
+	})
+}
+
+// HandleUpdateWebhook change the URL, the events or the state of a webhook
+func (m *Repo) HandleUpdateWebhook(c *gin.Context) {
+	hook, ok := m.findChannelWebhook(c)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		URL    *string  `json:"url"`
+		Events []string `json:"events"`
+		Active *bool    `json:"active"`
+	}
+
+	err := c.BindJSON(&payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload inputs"})
+		return
+	}
+
+	rawURL := hook.URL
+	if payload.URL != nil {
+		rawURL = strings.TrimSpace(*payload.URL)
+	}
+	events := hook.EventList
+	if payload.Events != nil {
+		events = payload.Events
+	}
+
+	v := validator.New()
+	hook.Events = validateWebhook(v, rawURL, events)
+	if !v.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": v.GetErrMsg()})
+		return
+	}
+
+	hook.URL = rawURL
+	if payload.Active != nil {
+		hook.Active = *payload.Active
+	}
+
+	err = m.App.DBMethods.UpdateWebhook(hook)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhook": hook})
+}
+
+// HandleDeleteWebhook delete a webhook of a channel with its delivery logs
+func (m *Repo) HandleDeleteWebhook(c *gin.Context) {
+	hook, ok := m.findChannelWebhook(c)
+	if !ok {
+		return
+	}
+
+	err := m.App.DBMethods.DeleteWebhook(hook.ChannelID, hook.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "The webhook has been deleted"})
+}
+
+// HandleGetWebhookDeliveries list the delivery logs of a webhook
+func (m *Repo) HandleGetWebhookDeliveries(c *gin.Context) {
+	hook, ok := m.findChannelWebhook(c)
+	if !ok {
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page number"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit number"})
+		return
+	}
+
+	deliveries, total, err := m.App.DBMethods.GetWebhookDeliveries(hook.ID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries, "total": total, "has_next_page": total > int64(page*limit), "page": page})
+}
+
+// HandleTestWebhook send a test event to a webhook right away, it is logged but not retried
+func (m *Repo) HandleTestWebhook(c *gin.Context) {
+	hook, ok := m.findChannelWebhook(c)
+	if !ok {
+		return
+	}
+
+	eventID, err := helpers.GenerateRandomToken(16)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send the test event"})
+		return
+	}
+
+	now := time.Now()
+	body, err := json.Marshal(models.WebhookPayload{
+		ID:        strings.TrimRight(eventID, "="),
+		Event:     models.EventWebhookTest,
+		ChannelID: hook.ChannelID,
+		CreatedAt: now,
+		Data:      map[string]interface{}{"webhook_id": hook.ID, "message": "This is a test event"},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send the test event"})
+		return
+	}
+
+	delivery := models.WebhookDelivery{
+		WebhookID: hook.ID,
+		Event:     models.EventWebhookTest,
+		Payload:   string(body),
+		Status:    models.DeliveryPending,
+		Attempts:  1,
+	}
+	err = m.App.DBMethods.CreateWebhookDelivery(&delivery)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	result, err := webhook.Send(ctx, hook.URL, hook.Secret, delivery.Event, delivery.ID, body)
+	delivery.ResponseStatus = result.Status
+	if err != nil {
+		delivery.Status = models.DeliveryFailed
+		delivery.Error = err.Error()
+		if len(delivery.Error) > 255 {
+			delivery.Error = delivery.Error[:255]
+		}
+	} else {
+		deliveredAt := time.Now()
+		delivery.Status = models.DeliverySucceeded
+		delivery.DeliveredAt = &deliveredAt
+	}
+
+	err = m.App.DBMethods.UpdateWebhookDelivery(&delivery)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"delivery": delivery})
+}
@@ -214,6 +214,17 @@ func (m *Repo) HandleMessages() {
 				}
 			}
 
+		case "video_status":
+			conn := m.Clients.Get(event.BroadcasterID)
+			if conn == nil {
+				continue
+			}
+
+			err := conn.WriteJSON(WsPayload{Action: event.Action, Data: event.Data})
+			if err != nil {
+				continue
+			}
+
 		case "notifications":
 			// get clients conn from map using broadcasterID
 			conn := m.Clients.Get(event.BroadcasterID)
@@ -3,17 +3,28 @@ package helpers
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
 	"mime/multipart"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/raihan2bd/vidverse/config"
+	"github.com/raihan2bd/vidverse/internal/media"
 	"github.com/raihan2bd/vidverse/models"
 )
 
@@ -48,10 +59,115 @@ func ValidateToken(claims jwt.MapClaims) bool {
 		return false
 	}
 
+	// a two-factor challenge is not a login
+	if _, ok := claims["typ"]; ok {
+		return false
+	}
+
 	// check the user_id from the token as well
 	return claims["sub"] != 0
 }
 
+// how long a user has to enter the two-factor code after the password
+const ChallengeTokenTTL = 5 * time.Minute
+
+// Create the token of a logged in user, mfa tells the user passed the two-factor check
+func CreateLoginToken(user *models.User, mfa bool) (string, int64, error) {
+	exp := time.Now().Add(time.Hour * 24 * 7).Unix()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":       user.ID,
+		"user_role": user.UserRole,
+		"user_name": user.Name,
+		"mfa":       mfa,
+		"exp":       exp,
+	})
+
+	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		return "", 0, err
+	}
+
+	return tokenString, exp, nil
+}
+
+// Create the short lived token a user trades with a two-factor code for a login token, fromOIDC tells the first step was a login with a provider
+func CreateChallengeToken(userID uint, fromOIDC bool) (string, int64, error) {
+	if fromOIDC {
+		return createTypedToken(userID, "2fa_challenge_oidc", ChallengeTokenTTL)
+	}
+	return createTypedToken(userID, "2fa_challenge", ChallengeTokenTTL)
+}
+
+// Get the user of a two-factor challenge token and whether the first step was a login with a provider
+func DecodeChallengeToken(tokenString string) (uint, bool, error) {
+	if userID, ok := decodeTypedToken(tokenString, "2fa_challenge"); ok {
+		return userID, false, nil
+	}
+
+	if userID, ok := decodeTypedToken(tokenString, "2fa_challenge_oidc"); ok {
+		return userID, true, nil
+	}
+
+	return 0, false, errors.New("invalid or expired challenge token")
+}
+
+// how long a login with a provider can stand in for the password of a user
+const ReauthTokenTTL = 10 * time.Minute
+
+// Create the short lived token proving a user just logged in with a provider, the users signed up with a provider have no password they know
+func CreateReauthToken(userID uint) (string, int64, error) {
+	return createTypedToken(userID, "reauth", ReauthTokenTTL)
+}
+
+// Get the user of a re-authentication token
+func DecodeReauthToken(tokenString string) (uint, error) {
+	userID, ok := decodeTypedToken(tokenString, "reauth")
+	if !ok {
+		return 0, errors.New("invalid or expired re-authentication token")
+	}
+
+	return userID, nil
+}
+
+// create a token of a user that is not a login token, typ tells what it is for
+func createTypedToken(userID uint, typ string, ttl time.Duration) (string, int64, error) {
+	exp := time.Now().Add(ttl).Unix()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": userID,
+		"typ": typ,
+		"exp": exp,
+	})
+
+	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		return "", 0, err
+	}
+
+	return tokenString, exp, nil
+}
+
+// get the user of a token created by createTypedToken for the same purpose
+func decodeTypedToken(tokenString, typ string) (uint, bool) {
+	claims, err := DecodeToken(tokenString)
+	if err != nil || claims == nil {
+		return 0, false
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok || float64(time.Now().Unix()) > exp || claims["typ"] != typ {
+		return 0, false
+	}
+
+	sub, ok := claims["sub"].(float64)
+	if !ok || sub <= 0 {
+		return 0, false
+	}
+
+	return uint(sub), true
+}
+
 // Validate user_id and convert to uint
 func ValidateAndGetUserByID(app *config.Application, id any) (*models.User, error) {
 	userID, ok := id.(uint)
@@ -134,3 +250,287 @@ func GenerateRandomToken(length int) (string, error) {
 	}
 	return base64.URLEncoding.EncodeToString(bytes), nil
 }
+
+// hash a random token before it is stored, the token is random enough for sha256
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generate a recovery code like "k3x9d-q2m7f" that is easy to type
+func GenerateRecoveryCode() (string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+	bytes := make([]byte, 10)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	code := make([]byte, 0, 11)
+	for i, b := range bytes {
+		if i == 5 {
+			code = append(code, '-')
+		}
+		code = append(code, alphabet[int(b)%len(alphabet)])
+	}
+
+	return string(code), nil
+}
+
+// split comma separated tags into a list of unique lower case tags
+func ParseTags(raw string) []string {
+	var tags []string
+	seen := map[string]bool{}
+
+	for _, tag := range strings.Split(raw, ",") {
+		tag = strings.ToLower(strings.Join(strings.Fields(strings.TrimPrefix(strings.TrimSpace(tag), "#")), " "))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// notify the subscribers of the channel about a newly published video
+func NotifySubscribers(app *config.Application, videoID uint) {
+	video, err := app.DBMethods.FindVideoByID(videoID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	DispatchWebhookEvent(app, video.ChannelID, models.EventVideoPublished, map[string]interface{}{
+		"video_id":    video.ID,
+		"title":       video.Title,
+		"description": video.Description,
+		"thumb":       video.Thumb,
+		"publish_at":  video.PublishAt,
+	})
+
+	subscriberIDs, err := app.DBMethods.GetSubscriberIDsByChannelID(video.ChannelID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, subscriberID := range subscriberIDs {
+		if subscriberID == video.Channel.UserID {
+			continue
+		}
+
+		notification := models.Notification{
+			ReceiverID: subscriberID,
+			SenderID:   video.Channel.UserID,
+			SenderName: video.Channel.Title,
+			ChannelID:  video.ChannelID,
+			VideoID:    video.ID,
+			IsRead:     false,
+			Type:       "new_video",
+		}
+
+		nID, err := app.DBMethods.CreateNotification(&notification)
+		if err != nil {
+			continue
+		}
+
+		notification.SenderAvatar = video.Channel.Logo
+		notification.Thumb = video.Thumb
+		notification.ID = nID
+
+		app.NotificationChan <- &config.NotificationEvent{
+			BroadcasterID: subscriberID,
+			Action:        "a_new_notification",
+			Data:          &notification,
+		}
+	}
+}
+
+// queue an event for the webhooks of a channel registered for it, the webhook worker sends them
+func DispatchWebhookEvent(app *config.Application, channelID uint, event string, data interface{}) {
+	webhooks, err := app.DBMethods.GetWebhooksForEvent(channelID, event)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	eventID, err := GenerateRandomToken(16)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	now := time.Now()
+	payload, err := json.Marshal(models.WebhookPayload{
+		ID:        strings.TrimRight(eventID, "="),
+		Event:     event,
+		ChannelID: channelID,
+		CreatedAt: now,
+		Data:      data,
+	})
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	deliveries := make([]models.WebhookDelivery, len(webhooks))
+	for i, webhook := range webhooks {
+		deliveries[i] = models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: &now,
+		}
+	}
+
+	err = app.DBMethods.CreateWebhookDeliveries(deliveries)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// build the thumbnail url cloudinary generates from the first frame of a video
+func GenerateVideoThumbURL(CLD *cloudinary.Cloudinary, publicID string) string {
+	return fmt.Sprintf("https://res.cloudinary.com/%s/video/upload/%s.jpeg", CLD.Config.Cloud.CloudName, publicID)
+}
+
+// UploadDir get the dir of the uploads waiting to be processed, UPLOAD_TMP_DIR gives each instance on a host its own dir
+func UploadDir() string {
+	if dir := os.Getenv("UPLOAD_TMP_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "vidverse-uploads")
+}
+
+// copy an uploaded file to a temporary file in the upload dir so it outlives the request
+func SaveToTempFile(file multipart.File, pattern string) (string, error) {
+	err := os.MkdirAll(UploadDir(), 0o700)
+	if err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(UploadDir(), pattern)
+	if err != nil {
+		return "", err
+	}
+	defer tmp.Close()
+
+	if _, err = io.Copy(tmp, file); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+
+	return tmp.Name(), nil
+}
+
+// render the image in every thumbnail size and upload them to cloudinary
+func StoreThumbnail(ctx context.Context, CLD *cloudinary.Cloudinary, imagePath string) (*models.VideoThumbnail, error) {
+	var thumbnail models.VideoThumbnail
+
+	for _, size := range media.ThumbnailSizes {
+		resized := fmt.Sprintf("%s-%s.jpg", strings.TrimSuffix(imagePath, filepath.Ext(imagePath)), size.Name)
+		err := media.ResizeImage(ctx, imagePath, size.Width, resized)
+		if err != nil {
+			DeleteThumbnailFromCloudinary(ctx, CLD, &thumbnail)
+			return nil, err
+		}
+
+		file, err := os.Open(resized)
+		if err != nil {
+			os.Remove(resized)
+			DeleteThumbnailFromCloudinary(ctx, CLD, &thumbnail)
+			return nil, err
+		}
+
+		secureURL, publicID, err := UploadImageToCloudinary(ctx, CLD, file, "vidverse/uploads/thumbs")
+		file.Close()
+		os.Remove(resized)
+		if err != nil {
+			DeleteThumbnailFromCloudinary(ctx, CLD, &thumbnail)
+			return nil, err
+		}
+
+		switch size.Name {
+		case "small":
+			thumbnail.Small, thumbnail.SmallPublicID = secureURL, publicID
+		case "medium":
+			thumbnail.Medium, thumbnail.MediumPublicID = secureURL, publicID
+		case "large":
+			thumbnail.Large, thumbnail.LargePublicID = secureURL, publicID
+		}
+	}
+
+	return &thumbnail, nil
+}
+
+// delete every size of a thumbnail from cloudinary
+func DeleteThumbnailFromCloudinary(ctx context.Context, CLD *cloudinary.Cloudinary, thumbnail *models.VideoThumbnail) {
+	for _, publicID := range []string{thumbnail.SmallPublicID, thumbnail.MediumPublicID, thumbnail.LargePublicID} {
+		if publicID != "" {
+			_ = DeleteImageFromCloudinary(ctx, CLD, publicID)
+		}
+	}
+}
+
+// crop the image to a square avatar and upload it to cloudinary
+func StoreAvatar(ctx context.Context, CLD *cloudinary.Cloudinary, imagePath string) (string, string, error) {
+	cropped := strings.TrimSuffix(imagePath, filepath.Ext(imagePath)) + "-avatar.jpg"
+	err := media.CropAvatar(ctx, imagePath, cropped)
+	if err != nil {
+		return "", "", err
+	}
+	defer os.Remove(cropped)
+
+	file, err := os.Open(cropped)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	return UploadImageToCloudinary(ctx, CLD, file, "vidverse/uploads/avatars")
+}
+
+// normalize a channel handle as typed by the user, "@My.Channel" becomes "my.channel"
+func NormalizeHandle(raw string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(raw), "@"))
+}
+
+// build a handle from the title of a channel, "My Cool Channel!" becomes "mycoolchannel"
+func SuggestHandle(title string) string {
+	var handle strings.Builder
+	for _, r := range strings.ToLower(title) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			handle.WriteRune(r)
+		}
+		if handle.Len() == 24 {
+			break
+		}
+	}
+
+	for handle.Len() < 3 {
+		handle.WriteString("0")
+	}
+
+	return handle.String()
+}
+
+// read the width and height of an uploaded image and rewind the file for the upload
+func ImageDimensions(file multipart.File) (int, int, error) {
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0, errors.New("failed to read the image")
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return 0, 0, errors.New("failed to read the image")
+	}
+
+	return config.Width, config.Height, nil
+}
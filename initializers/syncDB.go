@@ -10,8 +10,26 @@ import (
 )
 
 func SyncDatabase() error {
-	err := DB.AutoMigrate(&models.User{}, &models.Channel{}, &models.Video{}, &models.Like{}, &models.Comment{}, &models.Subscription{}, &models.Notification{}, &models.ContactUs{}, &models.Token{})
+	// captions are unique per video and language, keep the latest of the tracks uploaded twice before
+	if DB.Migrator().HasTable(&models.Caption{}) {
+		err := DB.Exec(`DELETE FROM captions WHERE id IN (
+			SELECT id FROM (SELECT id, row_number() OVER (PARTITION BY video_id, language ORDER BY updated_at DESC, id DESC) as position FROM captions) as ranked
+			WHERE ranked.position > 1)`).Error
+		if err != nil {
+			log.Println(err)
+			return errors.New("failed to sync database")
+		}
+	}
+
+	err := DB.AutoMigrate(&models.User{}, &models.Channel{}, &models.Video{}, &models.Like{}, &models.Comment{}, &models.Subscription{}, &models.Notification{}, &models.ContactUs{}, &models.Token{}, &models.WatchHistory{}, &models.TrendingVideo{}, &models.Tag{}, &models.VideoThumbnail{}, &models.VideoPreview{}, &models.Caption{}, &models.Chapter{}, &models.AnalyticsEvent{}, &models.ChannelDailyStat{}, &models.ChannelHandleRedirect{}, &models.ChannelMember{}, &models.ChannelInvite{}, &models.EmailChange{}, &models.DataExport{}, &models.RecoveryCode{}, &models.OIDCState{}, &models.UserIdentity{}, &models.APIKey{}, &models.Webhook{}, &models.WebhookDelivery{})
+
+	if err != nil {
+		log.Println(err)
+		return errors.New("failed to sync database")
+	}
 
+	// channels created before handles existed get one from their ID
+	err = DB.Exec("UPDATE channels SET handle = 'channel' || id WHERE handle IS NULL OR handle = ''").Error
 	if err != nil {
 		log.Println(err)
 		return errors.New("failed to sync database")
@@ -102,11 +120,13 @@ func seedChannels() {
 	channels := []models.Channel{
 		{
 			Title:       "Channel 1",
+			Handle:      "channel1",
 			Description: "Description for Channel 1",
 			UserID:      1,
 		},
 		{
 			Title:       "Channel 2",
+			Handle:      "channel2",
 			Description: "Description for Channel 2",
 			UserID:      2,
 		},
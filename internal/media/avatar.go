@@ -0,0 +1,15 @@
+package media
+
+import (
+	"context"
+	"fmt"
+)
+
+// the width and height avatars are stored at
+const AvatarSize = 256
+
+// CropAvatar crop the center square of the image and scale it to the avatar size
+func CropAvatar(ctx context.Context, src string, out string) error {
+	filter := fmt.Sprintf("crop='min(iw,ih)':'min(iw,ih)',scale=%d:%d", AvatarSize, AvatarSize)
+	return ffmpeg(ctx, "-i", src, "-vf", filter, "-frames:v", "1", "-q:v", "3", out)
+}
@@ -0,0 +1,153 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Cue is a single timed text of a caption track
+type Cue struct {
+	Start float64
+	End   float64
+	Text  string
+}
+
+var captionTags = regexp.MustCompile(`<[^>]*>`)
+
+// ParseCaptions parse a SRT or WebVTT file into its cues
+func ParseCaptions(data []byte) ([]Cue, error) {
+	content := strings.TrimPrefix(string(data), "\ufeff")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\r", "\n")
+
+	blocks := strings.Split(strings.TrimSpace(content), "\n\n")
+	isVTT := strings.HasPrefix(blocks[0], "WEBVTT")
+	if isVTT {
+		blocks = blocks[1:]
+	}
+
+	var cues []Cue
+	for _, block := range blocks {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+		if len(lines) == 0 || lines[0] == "" {
+			continue
+		}
+
+		// comments and styling blocks are only allowed in WebVTT and carry no text
+		if isVTT && (strings.HasPrefix(lines[0], "NOTE") || strings.HasPrefix(lines[0], "STYLE") || strings.HasPrefix(lines[0], "REGION")) {
+			continue
+		}
+
+		// the timing line may be preceded by a cue number or identifier
+		timing := 0
+		if !strings.Contains(lines[0], "-->") {
+			timing = 1
+		}
+		if timing >= len(lines) || !strings.Contains(lines[timing], "-->") {
+			return nil, fmt.Errorf("invalid cue %q: missing timing line", lines[0])
+		}
+
+		start, end, err := parseCueTiming(lines[timing])
+		if err != nil {
+			return nil, err
+		}
+
+		cues = append(cues, Cue{Start: start, End: end, Text: strings.Join(lines[timing+1:], "\n")})
+	}
+
+	if len(cues) == 0 {
+		return nil, errors.New("the caption file has no cues")
+	}
+
+	return cues, nil
+}
+
+// ValidateCues check every cue ends after it starts, the cues are in order and fit in the video
+func ValidateCues(cues []Cue, duration float64) error {
+	for i, cue := range cues {
+		if cue.End <= cue.Start {
+			return fmt.Errorf("cue %d ends before it starts", i+1)
+		}
+		if i > 0 && cue.Start < cues[i-1].Start {
+			return fmt.Errorf("cue %d starts before the previous cue", i+1)
+		}
+		if duration > 0 && cue.Start >= duration {
+			return fmt.Errorf("cue %d starts after the end of the video", i+1)
+		}
+	}
+
+	return nil
+}
+
+// BuildCaptionTrack write the cues as a WebVTT track
+func BuildCaptionTrack(cues []Cue) string {
+	var track strings.Builder
+	track.WriteString("WEBVTT\n")
+
+	for _, cue := range cues {
+		fmt.Fprintf(&track, "\n%s --> %s\n%s\n", FormatTimestamp(cue.Start), FormatTimestamp(cue.End), cue.Text)
+	}
+
+	return track.String()
+}
+
+// CaptionText join the text of the cues without any markup so it can be searched
+func CaptionText(cues []Cue) string {
+	texts := make([]string, 0, len(cues))
+	for _, cue := range cues {
+		text := strings.Join(strings.Fields(captionTags.ReplaceAllString(cue.Text, "")), " ")
+		if text != "" {
+			texts = append(texts, text)
+		}
+	}
+
+	return strings.Join(texts, " ")
+}
+
+// parse "00:00:01,000 --> 00:00:04,000" with optional WebVTT cue settings after the end time
+func parseCueTiming(line string) (float64, float64, error) {
+	parts := strings.SplitN(line, "-->", 2)
+	fields := strings.Fields(parts[1])
+	if len(fields) == 0 {
+		return 0, 0, fmt.Errorf("invalid cue timing %q", line)
+	}
+
+	start, err := parseCueTimestamp(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid cue timing %q", line)
+	}
+
+	end, err := parseCueTimestamp(fields[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid cue timing %q", line)
+	}
+
+	return start, end, nil
+}
+
+// parse "hh:mm:ss.mmm", "mm:ss.mmm" or the SRT form "hh:mm:ss,mmm" into seconds
+func parseCueTimestamp(value string) (float64, error) {
+	parts := strings.Split(strings.Replace(value, ",", ".", 1), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, errors.New("invalid timestamp")
+	}
+
+	var seconds float64
+	for i, part := range parts {
+		last := i == len(parts)-1
+		if part == "" || (!last && strings.Contains(part, ".")) {
+			return 0, errors.New("invalid timestamp")
+		}
+
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 || (i > 0 && n >= 60) {
+			return 0, errors.New("invalid timestamp")
+		}
+		seconds = seconds*60 + n
+	}
+
+	return seconds, nil
+}
@@ -0,0 +1,116 @@
+package media
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCaptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []Cue
+		wantErr bool
+	}{
+		{
+			name: "srt",
+			data: "1\n00:00:01,000 --> 00:00:04,500\nHello\n\n2\n00:00:05,000 --> 00:00:06,000\nTwo\nlines\n",
+			want: []Cue{{Start: 1, End: 4.5, Text: "Hello"}, {Start: 5, End: 6, Text: "Two\nlines"}},
+		},
+		{
+			name: "webvtt with header, note and cue settings",
+			data: "WEBVTT\n\nNOTE a comment\n\nintro\n00:01.000 --> 00:02.000 align:start\nHi\n",
+			want: []Cue{{Start: 1, End: 2, Text: "Hi"}},
+		},
+		{
+			name: "byte order mark and windows line endings",
+			data: "\ufeff1\r\n00:00:01,000 --> 00:00:02,000\r\nHi\r\n",
+			want: []Cue{{Start: 1, End: 2, Text: "Hi"}},
+		},
+		{
+			name: "hours in the timestamp",
+			data: "01:02:03.250 --> 01:02:04.000\nLate",
+			want: []Cue{{Start: 3723.25, End: 3724, Text: "Late"}},
+		},
+		{
+			name:    "empty file",
+			data:    "",
+			wantErr: true,
+		},
+		{
+			name:    "header only",
+			data:    "WEBVTT\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing timing line",
+			data:    "1\nHello\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid timestamp",
+			data:    "00:00:61,000 --> 00:01:02,000\nHi",
+			wantErr: true,
+		},
+		{
+			name:    "missing end time",
+			data:    "00:00:01,000 -->\nHi",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCaptions([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCaptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCaptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCues(t *testing.T) {
+	tests := []struct {
+		name     string
+		cues     []Cue
+		duration float64
+		wantErr  bool
+	}{
+		{name: "valid", cues: []Cue{{Start: 0, End: 1}, {Start: 1, End: 2}}, duration: 10},
+		{name: "unknown duration", cues: []Cue{{Start: 100, End: 101}}, duration: 0},
+		{name: "ends before it starts", cues: []Cue{{Start: 2, End: 1}}, duration: 10, wantErr: true},
+		{name: "out of order", cues: []Cue{{Start: 5, End: 6}, {Start: 1, End: 2}}, duration: 10, wantErr: true},
+		{name: "after the end of the video", cues: []Cue{{Start: 10, End: 11}}, duration: 10, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCues(tt.cues, tt.duration)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCaptionText(t *testing.T) {
+	tests := []struct {
+		name string
+		cues []Cue
+		want string
+	}{
+		{name: "no cues", cues: nil, want: ""},
+		{name: "strip markup and whitespace", cues: []Cue{{Text: "<i>Hello</i>\n  world"}, {Text: "<b></b>"}, {Text: "again"}}, want: "Hello world again"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CaptionText(tt.cues); got != tt.want {
+				t.Errorf("CaptionText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
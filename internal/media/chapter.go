@@ -0,0 +1,95 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// rules a list of timestamps has to follow to be shown as chapters
+const (
+	MinChapters        = 3
+	MinChapterLength   = 10
+	maxChapterTitleLen = 100
+)
+
+// Chapter is a titled section of a video starting at a timestamp
+type Chapter struct {
+	Start float64
+	End   float64
+	Title string
+}
+
+// lines like "0:00 Intro", "- 1:05:30 - Outro" or "(12:30) Q&A"
+var chapterLine = regexp.MustCompile(`^\s*(?:[-*•]\s*)?\(?((?:\d{1,2}:)?\d{1,2}:\d{2})\)?\s*(?:[-–—:|]\s*)?(\S.*)$`)
+
+// ParseChapters read the timestamps of a video description as chapters, a description without timestamps has no chapters
+func ParseChapters(description string, duration float64) ([]Chapter, error) {
+	var chapters []Chapter
+	for _, line := range strings.Split(description, "\n") {
+		match := chapterLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		start, err := parseChapterTimestamp(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid chapter timestamp %s", match[1])
+		}
+
+		title := strings.TrimSpace(match[2])
+		if runes := []rune(title); len(runes) > maxChapterTitleLen {
+			title = string(runes[:maxChapterTitleLen])
+		}
+
+		chapters = append(chapters, Chapter{Start: start, Title: title})
+	}
+
+	if len(chapters) == 0 {
+		return nil, nil
+	}
+
+	if chapters[0].Start != 0 {
+		return nil, errors.New("the first chapter must start at 0:00")
+	}
+	if len(chapters) < MinChapters {
+		return nil, fmt.Errorf("a video needs at least %d chapters", MinChapters)
+	}
+
+	for i := 1; i < len(chapters); i++ {
+		if chapters[i].Start <= chapters[i-1].Start {
+			return nil, fmt.Errorf("the chapter %q must start after the previous chapter", chapters[i].Title)
+		}
+	}
+
+	for i := range chapters {
+		if i+1 < len(chapters) {
+			chapters[i].End = chapters[i+1].Start
+		} else {
+			chapters[i].End = duration
+		}
+
+		// the length of the last chapter is only known when the duration is
+		if chapters[i].End > 0 && chapters[i].End-chapters[i].Start < MinChapterLength {
+			return nil, fmt.Errorf("the chapter %q must be at least %d seconds long", chapters[i].Title, MinChapterLength)
+		}
+	}
+
+	return chapters, nil
+}
+
+// parse "m:ss" or "h:mm:ss" into seconds
+func parseChapterTimestamp(value string) (float64, error) {
+	var seconds int
+	for i, part := range strings.Split(value, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil || (i > 0 && n >= 60) {
+			return 0, errors.New("invalid timestamp")
+		}
+		seconds = seconds*60 + n
+	}
+
+	return float64(seconds), nil
+}
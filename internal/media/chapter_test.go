@@ -0,0 +1,83 @@
+package media
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseChapters(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		duration    float64
+		want        []Chapter
+		wantErr     bool
+	}{
+		{
+			name:        "no timestamps",
+			description: "Just a description\nwith two lines",
+			duration:    60,
+			want:        nil,
+		},
+		{
+			name:        "plain chapters",
+			description: "My video\n0:00 Intro\n0:30 Setup\n1:00 Outro",
+			duration:    120,
+			want:        []Chapter{{Start: 0, End: 30, Title: "Intro"}, {Start: 30, End: 60, Title: "Setup"}, {Start: 60, End: 120, Title: "Outro"}},
+		},
+		{
+			name:        "bullets, brackets and separators",
+			description: "- 0:00 - Intro\n(0:45) Q&A\n• 1:02:03 | Outro",
+			duration:    0,
+			want:        []Chapter{{Start: 0, End: 45, Title: "Intro"}, {Start: 45, End: 3723, Title: "Q&A"}, {Start: 3723, End: 0, Title: "Outro"}},
+		},
+		{
+			name:        "first chapter not at zero",
+			description: "0:10 Intro\n0:30 Setup\n1:00 Outro",
+			duration:    120,
+			wantErr:     true,
+		},
+		{
+			name:        "too few chapters",
+			description: "0:00 Intro\n0:30 Outro",
+			duration:    120,
+			wantErr:     true,
+		},
+		{
+			name:        "out of order",
+			description: "0:00 Intro\n1:00 Setup\n0:30 Outro",
+			duration:    120,
+			wantErr:     true,
+		},
+		{
+			name:        "chapter too short",
+			description: "0:00 Intro\n0:05 Setup\n1:00 Outro",
+			duration:    120,
+			wantErr:     true,
+		},
+		{
+			name:        "last chapter too short",
+			description: "0:00 Intro\n0:30 Setup\n1:55 Outro",
+			duration:    120,
+			wantErr:     true,
+		},
+		{
+			name:        "invalid seconds",
+			description: "0:00 Intro\n0:75 Setup\n1:30 Outro",
+			duration:    120,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseChapters(tt.description, tt.duration)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseChapters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseChapters() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
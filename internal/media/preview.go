@@ -0,0 +1,104 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// size of a single tile of the seek-bar sprite sheet
+const (
+	TileWidth   = 160
+	TileHeight  = 90
+	tileColumns = 10
+	maxTiles    = 100
+)
+
+// Sprite describes how the frames are laid out in a sprite sheet
+type Sprite struct {
+	Interval float64
+	Columns  int
+	Count    int
+}
+
+// GeneratePreview render a short silent animated clip from the video, webp when possible otherwise gif
+func GeneratePreview(ctx context.Context, videoPath string, duration float64, out string) (string, error) {
+	start, length := previewWindow(duration)
+
+	filter := fmt.Sprintf("fps=10,scale=%d:-2", TileWidth*2)
+	webp := out + ".webp"
+	err := ffmpeg(ctx, "-ss", fmt.Sprintf("%.3f", start), "-t", fmt.Sprintf("%.3f", length), "-i", videoPath, "-an", "-vf", filter, "-loop", "0", "-c:v", "libwebp", "-q:v", "60", webp)
+	if err == nil {
+		return webp, nil
+	}
+
+	gif := out + ".gif"
+	err = ffmpeg(ctx, "-ss", fmt.Sprintf("%.3f", start), "-t", fmt.Sprintf("%.3f", length), "-i", videoPath, "-an", "-vf", filter, "-loop", "0", gif)
+	if err != nil {
+		return "", err
+	}
+	return gif, nil
+}
+
+// GenerateSprite tile evenly spaced frames of the video into a single jpeg image
+func GenerateSprite(ctx context.Context, videoPath string, duration float64, out string) (*Sprite, error) {
+	if duration <= 0 {
+		return nil, fmt.Errorf("can not build a sprite sheet for a video without duration")
+	}
+
+	sprite := spriteLayout(duration)
+	rows := int(math.Ceil(float64(sprite.Count) / float64(sprite.Columns)))
+
+	filter := fmt.Sprintf("fps=1/%g,scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,tile=%dx%d",
+		sprite.Interval, TileWidth, TileHeight, TileWidth, TileHeight, sprite.Columns, rows)
+
+	err := ffmpeg(ctx, "-i", videoPath, "-an", "-vf", filter, "-frames:v", "1", "-q:v", "4", out)
+	if err != nil {
+		return nil, err
+	}
+
+	return sprite, nil
+}
+
+// the part of the video the preview shows, 3 seconds from a fifth of the video or the whole of a shorter one
+func previewWindow(duration float64) (float64, float64) {
+	start, length := 0.0, 3.0
+	if duration > length {
+		start = duration * 0.2
+		if start+length > duration {
+			start = duration - length
+		}
+	}
+	return start, length
+}
+
+// one frame every interval of at least 2 seconds, with no more than maxTiles frames
+func spriteLayout(duration float64) *Sprite {
+	interval := math.Max(2, math.Ceil(duration/maxTiles))
+	count := int(math.Ceil(duration / interval))
+	return &Sprite{Interval: interval, Columns: tileColumns, Count: count}
+}
+
+// BuildThumbnailTrack write the WebVTT track pointing every time range to its tile in the sprite sheet
+func BuildThumbnailTrack(spriteURL string, sprite *Sprite, duration float64) string {
+	var track strings.Builder
+	track.WriteString("WEBVTT\n")
+
+	for i := 0; i < sprite.Count; i++ {
+		start := float64(i) * sprite.Interval
+		end := math.Min(start+sprite.Interval, duration)
+		x := (i % sprite.Columns) * TileWidth
+		y := (i / sprite.Columns) * TileHeight
+
+		fmt.Fprintf(&track, "\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n", FormatTimestamp(start), FormatTimestamp(end), spriteURL, x, y, TileWidth, TileHeight)
+	}
+
+	return track.String()
+}
+
+// FormatTimestamp format seconds as a WebVTT timestamp
+func FormatTimestamp(seconds float64) string {
+	millis := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", millis/3600000, millis/60000%60, millis/1000%60, millis%1000)
+}
@@ -0,0 +1,82 @@
+package media
+
+import "testing"
+
+func TestPreviewWindow(t *testing.T) {
+	tests := []struct {
+		name      string
+		duration  float64
+		wantStart float64
+	}{
+		{name: "no duration", duration: 0, wantStart: 0},
+		{name: "shorter than the preview", duration: 2, wantStart: 0},
+		{name: "a fifth in", duration: 100, wantStart: 20},
+		{name: "ends with the video", duration: 3.5, wantStart: 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, length := previewWindow(tt.duration)
+			if start != tt.wantStart || length != 3 {
+				t.Errorf("previewWindow(%g) = %g, %g, want %g, 3", tt.duration, start, length, tt.wantStart)
+			}
+		})
+	}
+}
+
+func TestSpriteLayout(t *testing.T) {
+	tests := []struct {
+		name         string
+		duration     float64
+		wantInterval float64
+		wantCount    int
+	}{
+		{name: "short video", duration: 3, wantInterval: 2, wantCount: 2},
+		{name: "every 2 seconds", duration: 10, wantInterval: 2, wantCount: 5},
+		{name: "longer interval", duration: 250, wantInterval: 3, wantCount: 84},
+		{name: "exactly the max tiles", duration: 1000, wantInterval: 10, wantCount: 100},
+		{name: "never more than the max tiles", duration: 1001, wantInterval: 11, wantCount: 91},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := spriteLayout(tt.duration)
+			if got.Interval != tt.wantInterval || got.Count != tt.wantCount || got.Columns != tileColumns {
+				t.Errorf("spriteLayout(%g) = %+v, want interval %g and %d tiles", tt.duration, *got, tt.wantInterval, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestBuildThumbnailTrack(t *testing.T) {
+	sprite := &Sprite{Interval: 2, Columns: 2, Count: 3}
+
+	got := BuildThumbnailTrack("https://cdn.example.com/s.jpg", sprite, 5)
+	want := "WEBVTT\n" +
+		"\n00:00:00.000 --> 00:00:02.000\nhttps://cdn.example.com/s.jpg#xywh=0,0,160,90\n" +
+		"\n00:00:02.000 --> 00:00:04.000\nhttps://cdn.example.com/s.jpg#xywh=160,0,160,90\n" +
+		"\n00:00:04.000 --> 00:00:05.000\nhttps://cdn.example.com/s.jpg#xywh=0,90,160,90\n"
+	if got != want {
+		t.Errorf("BuildThumbnailTrack() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	tests := []struct {
+		seconds float64
+		want    string
+	}{
+		{seconds: 0, want: "00:00:00.000"},
+		{seconds: 1.5, want: "00:00:01.500"},
+		{seconds: 59.9996, want: "00:01:00.000"},
+		{seconds: 3723.25, want: "01:02:03.250"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := FormatTimestamp(tt.seconds); got != tt.want {
+				t.Errorf("FormatTimestamp(%g) = %s, want %s", tt.seconds, got, tt.want)
+			}
+		})
+	}
+}
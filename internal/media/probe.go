@@ -0,0 +1,110 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotVideo is returned when the probed file is not a playable video
+var ErrNotVideo = errors.New("the file is not a valid video")
+
+// containers ffprobe reports for still images and raw streams which are not real videos
+var imageFormats = map[string]bool{
+	"image2":    true,
+	"png_pipe":  true,
+	"jpeg_pipe": true,
+	"webp_pipe": true,
+	"bmp_pipe":  true,
+	"gif":       true,
+	"tty":       true,
+}
+
+// Metadata is the information extracted from a video file
+type Metadata struct {
+	Duration float64
+	Width    int
+	Height   int
+	Codec    string
+	Bitrate  int64
+	Size     int64
+}
+
+type probeOutput struct {
+	Streams []struct {
+		CodecType string `json:"codec_type"`
+		CodecName string `json:"codec_name"`
+		Width     int    `json:"width"`
+		Height    int    `json:"height"`
+	} `json:"streams"`
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+		Size       string `json:"size"`
+		BitRate    string `json:"bit_rate"`
+	} `json:"format"`
+}
+
+func binary(key, fallback string) string {
+	if path := os.Getenv(key); path != "" {
+		return path
+	}
+	return fallback
+}
+
+// Probe read the metadata of a video file with ffprobe
+func Probe(ctx context.Context, path string) (*Metadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, binary("FFPROBE_PATH", "ffprobe"), "-v", "error", "-print_format", "json", "-show_format", "-show_streams", path)
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// ffprobe ran but could not read the file
+			return nil, ErrNotVideo
+		}
+		return nil, err
+	}
+
+	return parseProbe(out)
+}
+
+// read the metadata from the JSON output of ffprobe, images and files without a video stream are not videos
+func parseProbe(out []byte) (*Metadata, error) {
+	var probe probeOutput
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return nil, ErrNotVideo
+	}
+	for _, name := range strings.Split(probe.Format.FormatName, ",") {
+		if imageFormats[name] {
+			return nil, ErrNotVideo
+		}
+	}
+
+	metadata := &Metadata{}
+	metadata.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	metadata.Size, _ = strconv.ParseInt(probe.Format.Size, 10, 64)
+	metadata.Bitrate, _ = strconv.ParseInt(probe.Format.BitRate, 10, 64)
+
+	for _, stream := range probe.Streams {
+		if stream.CodecType == "video" {
+			metadata.Codec = stream.CodecName
+			metadata.Width = stream.Width
+			metadata.Height = stream.Height
+			break
+		}
+	}
+
+	if metadata.Codec == "" || metadata.Width <= 0 || metadata.Height <= 0 || metadata.Duration <= 0 {
+		return nil, ErrNotVideo
+	}
+
+	return metadata, nil
+}
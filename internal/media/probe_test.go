@@ -0,0 +1,70 @@
+package media
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseProbe(t *testing.T) {
+	tests := []struct {
+		name    string
+		out     string
+		want    Metadata
+		wantErr error
+	}{
+		{
+			name: "mp4 with audio first",
+			out: `{"streams":[{"codec_type":"audio","codec_name":"aac"},{"codec_type":"video","codec_name":"h264","width":1920,"height":1080}],
+				"format":{"format_name":"mov,mp4,m4a,3gp,3g2,mj2","duration":"12.480000","size":"1048576","bit_rate":"672000"}}`,
+			want: Metadata{Duration: 12.48, Width: 1920, Height: 1080, Codec: "h264", Bitrate: 672000, Size: 1048576},
+		},
+		{
+			name: "first video stream wins",
+			out: `{"streams":[{"codec_type":"video","codec_name":"vp9","width":640,"height":360},{"codec_type":"video","codec_name":"mjpeg","width":320,"height":180}],
+				"format":{"format_name":"matroska,webm","duration":"3.0"}}`,
+			want: Metadata{Duration: 3, Width: 640, Height: 360, Codec: "vp9"},
+		},
+		{
+			name:    "still image",
+			out:     `{"streams":[{"codec_type":"video","codec_name":"png","width":100,"height":100}],"format":{"format_name":"png_pipe","duration":"0.04"}}`,
+			wantErr: ErrNotVideo,
+		},
+		{
+			name:    "animated gif",
+			out:     `{"streams":[{"codec_type":"video","codec_name":"gif","width":100,"height":100}],"format":{"format_name":"gif","duration":"2.5"}}`,
+			wantErr: ErrNotVideo,
+		},
+		{
+			name:    "audio only",
+			out:     `{"streams":[{"codec_type":"audio","codec_name":"mp3"}],"format":{"format_name":"mp3","duration":"180.2"}}`,
+			wantErr: ErrNotVideo,
+		},
+		{
+			name:    "no duration",
+			out:     `{"streams":[{"codec_type":"video","codec_name":"h264","width":1280,"height":720}],"format":{"format_name":"mpegts","duration":"N/A"}}`,
+			wantErr: ErrNotVideo,
+		},
+		{
+			name:    "no dimensions",
+			out:     `{"streams":[{"codec_type":"video","codec_name":"h264"}],"format":{"format_name":"mov,mp4","duration":"5"}}`,
+			wantErr: ErrNotVideo,
+		},
+		{
+			name:    "invalid JSON",
+			out:     `not json`,
+			wantErr: ErrNotVideo,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseProbe([]byte(tt.out))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseProbe() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && *got != tt.want {
+				t.Errorf("parseProbe() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
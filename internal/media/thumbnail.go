@@ -0,0 +1,55 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// ThumbnailSize is a width a thumbnail is rendered at for responsive images
+type ThumbnailSize struct {
+	Name  string
+	Width int
+}
+
+var ThumbnailSizes = []ThumbnailSize{
+	{Name: "small", Width: 320},
+	{Name: "medium", Width: 640},
+	{Name: "large", Width: 1280},
+}
+
+func ffmpeg(ctx context.Context, args ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, binary("FFMPEG_PATH", "ffmpeg"), append([]string{"-v", "error", "-y"}, args...)...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg failed: %v: %s", err, out)
+	}
+	return nil
+}
+
+// ExtractFrame save the frame of the video at the given second as a jpeg image
+func ExtractFrame(ctx context.Context, videoPath string, at float64, out string) error {
+	return ffmpeg(ctx, "-ss", fmt.Sprintf("%.3f", at), "-i", videoPath, "-frames:v", "1", "-q:v", "2", out)
+}
+
+// ResizeImage scale the image to the given width keeping its aspect ratio
+func ResizeImage(ctx context.Context, src string, width int, out string) error {
+	return ffmpeg(ctx, "-i", src, "-vf", fmt.Sprintf("scale='min(%d,iw)':-2", width), "-q:v", "3", out)
+}
+
+// FrameTimestamps spread the given number of candidate frames over the video, skipping the very start and end
+func FrameTimestamps(duration float64, count int) []float64 {
+	timestamps := make([]float64, 0, count)
+	if duration <= 0 {
+		return append(timestamps, 0)
+	}
+
+	for i := 1; i <= count; i++ {
+		timestamps = append(timestamps, duration*float64(i)/float64(count+1))
+	}
+	return timestamps
+}
@@ -0,0 +1,273 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID = "vidverse"
+	testCode     = "the-code"
+	testNonce    = "the-nonce"
+)
+
+// a provider serving the discovery document, the signing keys and the token endpoint of a login
+type mockProvider struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	challenge string
+	// the ID token the token endpoint sends
+	idToken func(issuer string) string
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock := &mockProvider{key: key}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"issuer":                 mock.server.URL,
+			"authorization_endpoint": mock.server.URL + "/authorize",
+			"token_endpoint":         mock.server.URL + "/token",
+			"jwks_uri":               mock.server.URL + "/jwks",
+		})
+	})
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "test",
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		err := r.ParseForm()
+		if err != nil || r.Form.Get("grant_type") != "authorization_code" || r.Form.Get("client_id") != testClientID || r.Form.Get("code") != testCode {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+			return
+		}
+
+		// the verifier of the exchange must match the challenge of the authorization request
+		if CodeChallenge(r.Form.Get("code_verifier")) != mock.challenge {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]string{"id_token": mock.idToken(mock.server.URL)})
+	})
+
+	mock.server = httptest.NewServer(mux)
+	t.Cleanup(mock.server.Close)
+
+	return mock
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// sign an ID token with a key, the claims of a valid login are changed by edit
+func signIDToken(t *testing.T, key *rsa.PrivateKey, kid, issuer string, edit func(jwt.MapClaims)) string {
+	t.Helper()
+
+	claims := jwt.MapClaims{
+		"iss":            issuer,
+		"aud":            testClientID,
+		"sub":            "user-1",
+		"email":          "jane@example.com",
+		"email_verified": true,
+		"name":           "Jane",
+		"nonce":          testNonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+	if edit != nil {
+		edit(claims)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestExchange(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		verifier string
+		edit     func(jwt.MapClaims)
+		otherKey bool
+		want     *Claims
+		wantErr  bool
+	}{
+		{
+			name: "valid login",
+			want: &Claims{Subject: "user-1", Email: "jane@example.com", EmailVerified: true, Name: "Jane"},
+		},
+		{
+			name: "email verified as a string",
+			edit: func(c jwt.MapClaims) { c["email_verified"] = "true" },
+			want: &Claims{Subject: "user-1", Email: "jane@example.com", EmailVerified: true, Name: "Jane"},
+		},
+		{
+			name: "email not verified",
+			edit: func(c jwt.MapClaims) { delete(c, "email_verified") },
+			want: &Claims{Subject: "user-1", Email: "jane@example.com", Name: "Jane"},
+		},
+		{name: "wrong PKCE verifier", verifier: "another-verifier", wantErr: true},
+		{name: "wrong nonce", edit: func(c jwt.MapClaims) { c["nonce"] = "another-nonce" }, wantErr: true},
+		{name: "wrong audience", edit: func(c jwt.MapClaims) { c["aud"] = "another-client" }, wantErr: true},
+		{name: "wrong issuer", edit: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, wantErr: true},
+		{name: "expired", edit: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, wantErr: true},
+		{name: "no expiration", edit: func(c jwt.MapClaims) { delete(c, "exp") }, wantErr: true},
+		{name: "no subject", edit: func(c jwt.MapClaims) { delete(c, "sub") }, wantErr: true},
+		{name: "signed with an unknown key", otherKey: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := newMockProvider(t)
+			mock.idToken = func(issuer string) string {
+				if tt.otherKey {
+					return signIDToken(t, otherKey, "test", issuer, tt.edit)
+				}
+				return signIDToken(t, mock.key, "test", issuer, tt.edit)
+			}
+
+			ctx := context.Background()
+			provider, err := Discover(ctx, Config{
+				Name:         "mock",
+				DiscoveryURL: mock.server.URL + "/.well-known/openid-configuration",
+				ClientID:     testClientID,
+				RedirectURL:  "http://localhost:3000/callback",
+			})
+			if err != nil {
+				t.Fatalf("Discover() error = %v", err)
+			}
+
+			verifier, err := NewVerifier()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the provider keeps the challenge the browser brings to the authorization endpoint
+			authURL, err := url.Parse(provider.AuthCodeURL("the-state", testNonce, verifier))
+			if err != nil {
+				t.Fatal(err)
+			}
+			mock.challenge = authURL.Query().Get("code_challenge")
+
+			if tt.verifier != "" {
+				verifier = tt.verifier
+			}
+
+			got, err := provider.Exchange(ctx, testCode, verifier, testNonce)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Exchange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want != nil && *got != *tt.want {
+				t.Errorf("Exchange() = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	mock := newMockProvider(t)
+	provider, err := Discover(context.Background(), Config{
+		Name:         "mock",
+		DiscoveryURL: mock.server.URL + "/.well-known/openid-configuration",
+		ClientID:     testClientID,
+		RedirectURL:  "http://localhost:3000/callback",
+	})
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	authURL, err := url.Parse(provider.AuthCodeURL("the-state", testNonce, "the-verifier"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          "http://localhost:3000/callback",
+		"scope":                 "openid email profile",
+		"state":                 "the-state",
+		"nonce":                 testNonce,
+		"code_challenge":        CodeChallenge("the-verifier"),
+		"code_challenge_method": "S256",
+	}
+	for param, value := range want {
+		if got := authURL.Query().Get(param); got != value {
+			t.Errorf("%s = %q, want %q", param, got, value)
+		}
+	}
+	if authURL.Path != "/authorize" {
+		t.Errorf("path = %q, want /authorize", authURL.Path)
+	}
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// the example of RFC 7636 appendix B
+	got := CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	if want := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"; got != want {
+		t.Errorf("CodeChallenge() = %s, want %s", got, want)
+	}
+}
+
+func TestDiscoverIncomplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"issuer": "https://example.com"})
+	}))
+	defer server.Close()
+
+	_, err := Discover(context.Background(), Config{Name: "broken", DiscoveryURL: server.URL})
+	if err == nil {
+		t.Errorf("Discover() of an incomplete document did not fail")
+	}
+}
+
+func TestRegistryUnknownProvider(t *testing.T) {
+	registry := NewRegistry([]Config{{Name: "mock"}})
+
+	_, err := registry.Get(context.Background(), "other")
+	if !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("Get() error = %v, want ErrUnknownProvider", err)
+	}
+}
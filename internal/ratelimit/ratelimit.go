@@ -0,0 +1,179 @@
+// Package ratelimit keeps its counters in the memory of the process, they are not shared
+// between instances of the app and every limit and lockout starts over after a restart
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// how often the entries nobody used for a while are dropped
+const sweepInterval = 10 * time.Minute
+
+type window struct {
+	start time.Time
+	hits  int
+}
+
+// Limiter allows a number of hits per key in a fixed window of time
+type Limiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	hits      map[string]*window
+	lastSweep time.Time
+}
+
+// New create a limiter allowing limit hits per key in every window
+func New(limit int, every time.Duration) *Limiter {
+	return &Limiter{
+		limit:     limit,
+		window:    every,
+		hits:      make(map[string]*window),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow count a hit of a key, when the limit is reached the time to wait is returned
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) > sweepInterval {
+		for k, w := range l.hits {
+			if now.Sub(w.start) >= l.window {
+				delete(l.hits, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	w, ok := l.hits[key]
+	if !ok || now.Sub(w.start) >= l.window {
+		w = &window{start: now}
+		l.hits[key] = w
+	}
+
+	if w.hits >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+
+	w.hits++
+	return true, 0
+}
+
+type attempts struct {
+	failures    int
+	nextAllowed time.Time
+	lockedUntil time.Time
+	last        time.Time
+}
+
+// Backoff tracks failed attempts per key, after the free attempts every failure doubles the wait until the key is locked
+type Backoff struct {
+	mu        sync.Mutex
+	free      int
+	base      time.Duration
+	max       time.Duration
+	lockAfter int
+	lockFor   time.Duration
+	entries   map[string]*attempts
+	lastSweep time.Time
+}
+
+// NewBackoff create a tracker of failed attempts
+func NewBackoff(free int, base, max time.Duration, lockAfter int, lockFor time.Duration) *Backoff {
+	return &Backoff{
+		free:      free,
+		base:      base,
+		max:       max,
+		lockAfter: lockAfter,
+		lockFor:   lockFor,
+		entries:   make(map[string]*attempts),
+		lastSweep: time.Now(),
+	}
+}
+
+// Wait get how long a key has to wait before its next attempt
+func (b *Backoff) Wait(key string) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	a, ok := b.entries[key]
+	if !ok {
+		return 0
+	}
+
+	until := a.nextAllowed
+	if a.lockedUntil.After(until) {
+		until = a.lockedUntil
+	}
+
+	wait := time.Until(until)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// Fail count a failed attempt of a key, it tells whether the key just got locked
+func (b *Backoff) Fail(key string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.sweep(now)
+
+	a, ok := b.entries[key]
+	if !ok {
+		a = &attempts{}
+		b.entries[key] = a
+	}
+
+	// the failures before a lockout are forgotten once it is over
+	if !a.lockedUntil.IsZero() && now.After(a.lockedUntil) {
+		a.failures = 0
+		a.lockedUntil = time.Time{}
+	}
+
+	a.failures++
+	a.last = now
+
+	if a.failures >= b.lockAfter {
+		a.lockedUntil = now.Add(b.lockFor)
+		return true
+	}
+
+	if a.failures > b.free {
+		wait := b.base << uint(a.failures-b.free-1)
+		if wait <= 0 || wait > b.max {
+			wait = b.max
+		}
+		a.nextAllowed = now.Add(wait)
+	}
+
+	return false
+}
+
+// Reset forget the failed attempts of a key after a success
+func (b *Backoff) Reset(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.entries, key)
+}
+
+// drop the keys without a failure for longer than a lockout
+func (b *Backoff) sweep(now time.Time) {
+	if now.Sub(b.lastSweep) < sweepInterval {
+		return
+	}
+
+	for key, a := range b.entries {
+		if now.Sub(a.last) > b.lockFor+b.max && now.After(a.lockedUntil) {
+			delete(b.entries, key)
+		}
+	}
+	b.lastSweep = now
+}
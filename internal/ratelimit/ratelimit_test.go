@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	tests := []struct {
+		name        string
+		limit       int
+		hits        int
+		wantAllowed int
+	}{
+		{name: "under the limit", limit: 3, hits: 2, wantAllowed: 2},
+		{name: "at the limit", limit: 3, hits: 3, wantAllowed: 3},
+		{name: "over the limit", limit: 3, hits: 5, wantAllowed: 3},
+		{name: "limit of one", limit: 1, hits: 4, wantAllowed: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := New(tt.limit, time.Minute)
+
+			allowed := 0
+			for i := 0; i < tt.hits; i++ {
+				ok, wait := limiter.Allow("1.2.3.4")
+				if ok {
+					allowed++
+					continue
+				}
+				if wait <= 0 || wait > time.Minute {
+					t.Errorf("Allow() wait = %v, want within the window", wait)
+				}
+			}
+
+			if allowed != tt.wantAllowed {
+				t.Errorf("allowed %d hits, want %d", allowed, tt.wantAllowed)
+			}
+		})
+	}
+}
+
+func TestLimiterKeysAndWindows(t *testing.T) {
+	limiter := New(1, time.Minute)
+
+	if ok, _ := limiter.Allow("a"); !ok {
+		t.Fatalf("first hit of a was refused")
+	}
+	if ok, _ := limiter.Allow("b"); !ok {
+		t.Errorf("the hits of a counted for b")
+	}
+	if ok, _ := limiter.Allow("a"); ok {
+		t.Errorf("second hit of a was allowed")
+	}
+
+	// pretend the window of a is over
+	limiter.hits["a"].start = time.Now().Add(-time.Minute)
+	if ok, _ := limiter.Allow("a"); !ok {
+		t.Errorf("hit of a in a new window was refused")
+	}
+}
+
+func TestBackoffFail(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int
+		wantWait   time.Duration
+		wantLocked bool
+	}{
+		{name: "free attempts", failures: 2, wantWait: 0},
+		{name: "first wait", failures: 3, wantWait: time.Second},
+		{name: "wait doubles", failures: 4, wantWait: 2 * time.Second},
+		{name: "wait is capped", failures: 6, wantWait: 4 * time.Second},
+		{name: "locked", failures: 8, wantWait: time.Minute, wantLocked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backoff := NewBackoff(2, time.Second, 4*time.Second, 8, time.Minute)
+
+			locked := false
+			for i := 0; i < tt.failures; i++ {
+				locked = backoff.Fail("user")
+			}
+
+			if locked != tt.wantLocked {
+				t.Errorf("Fail() locked = %v, want %v", locked, tt.wantLocked)
+			}
+
+			wait := backoff.Wait("user")
+			if wait > tt.wantWait || (tt.wantWait > 0 && wait <= tt.wantWait-time.Second) {
+				t.Errorf("Wait() = %v, want about %v", wait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestBackoffReset(t *testing.T) {
+	backoff := NewBackoff(0, time.Second, time.Minute, 10, time.Minute)
+	backoff.Fail("user")
+
+	if backoff.Wait("user") == 0 {
+		t.Fatalf("Wait() = 0 after a failure without free attempts")
+	}
+	if backoff.Wait("other") != 0 {
+		t.Errorf("the failures of user counted for other")
+	}
+
+	backoff.Reset("user")
+	if wait := backoff.Wait("user"); wait != 0 {
+		t.Errorf("Wait() after Reset() = %v, want 0", wait)
+	}
+}
+
+func TestBackoffLockoutExpires(t *testing.T) {
+	backoff := NewBackoff(0, time.Second, time.Second, 2, time.Minute)
+	backoff.Fail("user")
+	if !backoff.Fail("user") {
+		t.Fatalf("Fail() did not lock the key")
+	}
+
+	// pretend the lockout is over, the failures before it are forgotten
+	backoff.entries["user"].lockedUntil = time.Now().Add(-time.Second)
+	if backoff.Fail("user") {
+		t.Errorf("first failure after the lockout locked the key again")
+	}
+}
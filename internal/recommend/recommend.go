@@ -0,0 +1,216 @@
+package recommend
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// weights of every signal used to score a related video
+const (
+	coLikeWeight      = 3.0
+	coWatchWeight     = 2.0
+	sharedTagWeight   = 1.5
+	textWeight        = 4.0
+	sameChannelWeight = 0.5
+)
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "how": true, "in": true, "is": true, "it": true, "of": true, "on": true,
+	"or": true, "that": true, "the": true, "this": true, "to": true, "was": true, "what": true,
+	"with": true, "you": true, "your": true,
+}
+
+type cacheEntry struct {
+	videoIDs  []uint
+	expiresAt time.Time
+}
+
+// Engine ranks related videos and caches the result per video
+type Engine struct {
+	sync.RWMutex
+	ttl       time.Duration
+	cache     map[uint]cacheEntry
+	contains  map[uint]map[uint]bool // video id -> the videos whose cached list contains it
+	lastSweep time.Time
+}
+
+func New(ttl time.Duration) *Engine {
+	return &Engine{
+		ttl:       ttl,
+		cache:     map[uint]cacheEntry{},
+		contains:  map[uint]map[uint]bool{},
+		lastSweep: time.Now(),
+	}
+}
+
+// Get the cached related video ids of a video
+func (e *Engine) Get(videoID uint) ([]uint, bool) {
+	e.RLock()
+	defer e.RUnlock()
+	entry, ok := e.cache[videoID]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.videoIDs, true
+}
+
+// Set the related video ids of a video
+func (e *Engine) Set(videoID uint, videoIDs []uint) {
+	e.Lock()
+	defer e.Unlock()
+
+	now := time.Now()
+	if now.Sub(e.lastSweep) >= e.ttl {
+		e.sweep(now)
+	}
+
+	e.remove(videoID)
+	e.cache[videoID] = cacheEntry{videoIDs: videoIDs, expiresAt: now.Add(e.ttl)}
+	for _, id := range videoIDs {
+		if e.contains[id] == nil {
+			e.contains[id] = map[uint]bool{}
+		}
+		e.contains[id][videoID] = true
+	}
+}
+
+// Invalidate remove the cached result of a video and every cached result that lists it
+func (e *Engine) Invalidate(videoID uint) {
+	e.Lock()
+	defer e.Unlock()
+	e.remove(videoID)
+	for source := range e.contains[videoID] {
+		e.remove(source)
+	}
+	delete(e.contains, videoID)
+}
+
+// remove the cached result of a video and its entries in the reverse index
+func (e *Engine) remove(videoID uint) {
+	entry, ok := e.cache[videoID]
+	if !ok {
+		return
+	}
+	for _, id := range entry.videoIDs {
+		delete(e.contains[id], videoID)
+		if len(e.contains[id]) == 0 {
+			delete(e.contains, id)
+		}
+	}
+	delete(e.cache, videoID)
+}
+
+// drop every expired result
+func (e *Engine) sweep(now time.Time) {
+	for videoID, entry := range e.cache {
+		if now.After(entry.expiresAt) {
+			e.remove(videoID)
+		}
+	}
+	e.lastSweep = now
+}
+
+// Keywords return the distinct words of the text used to look up text related candidates
+func Keywords(text string, max int) []string {
+	words := make([]string, 0, max)
+	for word := range terms(text) {
+		words = append(words, word)
+	}
+
+	// prefer the longer words as they tend to carry more meaning
+	sort.Slice(words, func(i, j int) bool {
+		if len(words[i]) != len(words[j]) {
+			return len(words[i]) > len(words[j])
+		}
+		return words[i] < words[j]
+	})
+
+	if len(words) > max {
+		words = words[:max]
+	}
+	return words
+}
+
+// Rank score the candidates against the source video and return the best video ids
+func (e *Engine) Rank(source *models.Video, candidates []models.RelatedCandidate, limit int) []uint {
+	sourceTerms := terms(source.Title + " " + source.Description)
+
+	type scored struct {
+		id    uint
+		score float64
+	}
+
+	var results []scored
+	for _, candidate := range candidates {
+		if candidate.ID == source.ID {
+			continue
+		}
+
+		score := coLikeWeight*float64(candidate.CoLikes) +
+			coWatchWeight*float64(candidate.CoWatches) +
+			sharedTagWeight*float64(candidate.SharedTags) +
+			textWeight*similarity(sourceTerms, terms(candidate.Title+" "+candidate.Description))
+
+		if candidate.ChannelID == source.ChannelID {
+			score += sameChannelWeight
+		}
+
+		if score <= 0 {
+			continue
+		}
+		results = append(results, scored{id: candidate.ID, score: score})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score > results[j].score
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	videoIDs := make([]uint, 0, len(results))
+	for _, result := range results {
+		videoIDs = append(videoIDs, result.id)
+	}
+
+	return videoIDs
+}
+
+// split the text into a set of lower case words without the stop words
+func terms(text string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		if len(word) < 2 || stopWords[word] {
+			continue
+		}
+		set[word] = true
+	}
+	return set
+}
+
+// jaccard similarity of two sets of words
+func similarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	var shared int
+	for word := range a {
+		if b[word] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
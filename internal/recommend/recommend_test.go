@@ -0,0 +1,175 @@
+package recommend
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/raihan2bd/vidverse/models"
+)
+
+func TestRank(t *testing.T) {
+	source := &models.Video{Title: "Learn Go concurrency", Description: "goroutines and channels", ChannelID: 1}
+	source.ID = 1
+
+	tests := []struct {
+		name       string
+		candidates []models.RelatedCandidate
+		limit      int
+		want       []uint
+	}{
+		{
+			name:       "no candidates",
+			candidates: nil,
+			limit:      10,
+			want:       []uint{},
+		},
+		{
+			name: "skip the source video",
+			candidates: []models.RelatedCandidate{
+				{ID: 1, Title: "Learn Go concurrency", ChannelID: 1, CoLikes: 5},
+				{ID: 2, CoLikes: 1},
+			},
+			limit: 10,
+			want:  []uint{2},
+		},
+		{
+			name: "skip candidates without any signal",
+			candidates: []models.RelatedCandidate{
+				{ID: 2, Title: "Cooking pasta", ChannelID: 2},
+				{ID: 3, SharedTags: 1, ChannelID: 2},
+			},
+			limit: 10,
+			want:  []uint{3},
+		},
+		{
+			name: "co-likes outweigh co-watches",
+			candidates: []models.RelatedCandidate{
+				{ID: 2, CoWatches: 1, ChannelID: 2},
+				{ID: 3, CoLikes: 1, ChannelID: 2},
+			},
+			limit: 10,
+			want:  []uint{3, 2},
+		},
+		{
+			name: "text similarity counts",
+			candidates: []models.RelatedCandidate{
+				{ID: 2, Title: "Cooking pasta", ChannelID: 1},
+				{ID: 3, Title: "Go concurrency patterns", Description: "channels", ChannelID: 2},
+			},
+			limit: 10,
+			want:  []uint{3, 2},
+		},
+		{
+			name: "ties keep the candidate order",
+			candidates: []models.RelatedCandidate{
+				{ID: 4, SharedTags: 2, ChannelID: 2},
+				{ID: 2, SharedTags: 2, ChannelID: 2},
+				{ID: 3, SharedTags: 2, ChannelID: 2},
+			},
+			limit: 10,
+			want:  []uint{4, 2, 3},
+		},
+		{
+			name: "limit the result",
+			candidates: []models.RelatedCandidate{
+				{ID: 2, CoLikes: 1},
+				{ID: 3, CoLikes: 3},
+				{ID: 4, CoLikes: 2},
+			},
+			limit: 2,
+			want:  []uint{3, 4},
+		},
+	}
+
+	engine := New(time.Minute)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := engine.Rank(source, tt.candidates, tt.limit)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Rank() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeywords(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		max  int
+		want []string
+	}{
+		{name: "empty", text: "", max: 5, want: []string{}},
+		{name: "drop stop words and short words", text: "The art of a B movie", max: 5, want: []string{"movie", "art"}},
+		{name: "lower case and distinct", text: "Go GO go golang", max: 5, want: []string{"golang", "go"}},
+		{name: "split on punctuation", text: "rock'n'roll, live!", max: 5, want: []string{"live", "rock", "roll"}},
+		{name: "keep the longest words", text: "tiny medium enormous", max: 2, want: []string{"enormous", "medium"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Keywords(tt.text, tt.max)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Keywords(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		invalidate  uint
+		wantCached  []uint
+		wantDropped []uint
+	}{
+		{name: "own result", invalidate: 1, wantCached: []uint{2, 3}, wantDropped: []uint{1}},
+		{name: "lists containing the video", invalidate: 5, wantCached: []uint{3}, wantDropped: []uint{1, 2}},
+		{name: "unknown video", invalidate: 9, wantCached: []uint{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := New(time.Minute)
+			engine.Set(1, []uint{4, 5})
+			engine.Set(2, []uint{5, 6})
+			engine.Set(3, []uint{7})
+
+			engine.Invalidate(tt.invalidate)
+
+			for _, id := range tt.wantCached {
+				if _, ok := engine.Get(id); !ok {
+					t.Errorf("Get(%d) missing, want cached", id)
+				}
+			}
+			for _, id := range tt.wantDropped {
+				if _, ok := engine.Get(id); ok {
+					t.Errorf("Get(%d) cached, want dropped", id)
+				}
+			}
+		})
+	}
+}
+
+func TestSetEvictsExpired(t *testing.T) {
+	engine := New(time.Minute)
+	engine.Set(1, []uint{2})
+	engine.Set(3, []uint{2})
+
+	// pretend the entries and the last sweep are older than the ttl
+	engine.cache[1] = cacheEntry{videoIDs: []uint{2}, expiresAt: time.Now().Add(-time.Second)}
+	engine.lastSweep = time.Now().Add(-2 * time.Minute)
+
+	engine.Set(4, []uint{5})
+
+	if _, ok := engine.cache[1]; ok {
+		t.Errorf("expired entry was not evicted")
+	}
+	if _, ok := engine.cache[3]; !ok {
+		t.Errorf("live entry was evicted")
+	}
+	if engine.contains[2][1] {
+		t.Errorf("reverse index still points at the evicted entry")
+	}
+}
@@ -0,0 +1,150 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress is returned for an endpoint on the local machine or a private network
+var ErrPrivateAddress = errors.New("the endpoint resolves to a private or local address")
+
+// ranges that are not reachable on the internet and that net.IP does not tell apart
+var reservedNetworks = parseNetworks("0.0.0.0/8", "100.64.0.0/10", "192.0.0.0/24", "198.18.0.0/15", "240.0.0.0/4", "64:ff9b::/96")
+
+// the client never follows redirects and only connects to public addresses, the check runs on the resolved IP
+// so a host name pointing to an internal address is refused too
+var httpClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: publicOnly,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     30 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
+
+// PublicIP tell an address can be reached on the internet, loopback, private, link-local and unspecified ones can not
+func PublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PublicHost tell the host of an endpoint may be public, an IP is checked now and a name once it is resolved
+func PublicHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		return PublicIP(ip)
+	}
+
+	return true
+}
+
+// the dialer hook refusing the private addresses, it gets the address after the DNS lookup
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !PublicIP(ip) {
+		return ErrPrivateAddress
+	}
+
+	return nil
+}
+
+// Sign compute the signature of a body, the timestamp is signed too so an old delivery can not be replayed
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Result is what the endpoint answered to a delivery, its body is not kept
+type Result struct {
+	Status int
+}
+
+// OK tell the endpoint accepted the delivery
+func (r Result) OK() bool {
+	return r.Status >= 200 && r.Status < 300
+}
+
+// Send post a signed payload to an endpoint
+func Send(ctx context.Context, url, secret, event string, deliveryID uint, body []byte) (Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "VidVerse-Webhooks/1.0")
+	req.Header.Set("X-VidVerse-Event", event)
+	req.Header.Set("X-VidVerse-Delivery", strconv.FormatUint(uint64(deliveryID), 10))
+	req.Header.Set("X-VidVerse-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-VidVerse-Signature", Sign(secret, timestamp, body))
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer res.Body.Close()
+
+	// read a bit of the body so the connection can be used again
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+
+	result := Result{Status: res.StatusCode}
+	if !result.OK() {
+		return result, fmt.Errorf("the endpoint answered with status %d", res.StatusCode)
+	}
+
+	return result, nil
+}
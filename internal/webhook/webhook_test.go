@@ -0,0 +1,188 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      string
+		want      string
+	}{
+		{
+			name:      "payload",
+			secret:    "whsec_test",
+			timestamp: 1700000000,
+			body:      `{"event":"webhook.test"}`,
+			want:      "sha256=1d83e338c8b0156315e4b02e94f96d83e4260098aa05dead2a856d36245f342f",
+		},
+		{
+			name:      "other secret",
+			secret:    "other",
+			timestamp: 1700000000,
+			body:      `{"event":"webhook.test"}`,
+			want:      "sha256=f9cf93345e9a8b55c4c2e778224ccda2dfec9db6fb857178a7c14e53bb61dc2c",
+		},
+		{
+			name:      "empty body",
+			secret:    "whsec_test",
+			timestamp: 1700000001,
+			body:      "",
+			want:      "sha256=15d6e9a5656f2374668fe56b290a7674ccfcec9a28bfba969f0e48d23489f271",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Sign(tt.secret, tt.timestamp, []byte(tt.body))
+			if got != tt.want {
+				t.Errorf("Sign() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "93.184.216.34", want: true},
+		{ip: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{ip: "127.0.0.1", want: false},
+		{ip: "::1", want: false},
+		{ip: "10.1.2.3", want: false},
+		{ip: "172.16.0.1", want: false},
+		{ip: "192.168.1.1", want: false},
+		{ip: "169.254.169.254", want: false},
+		{ip: "fe80::1", want: false},
+		{ip: "fd00::1", want: false},
+		{ip: "0.0.0.0", want: false},
+		{ip: "::", want: false},
+		{ip: "100.64.0.1", want: false},
+		{ip: "::ffff:127.0.0.1", want: false},
+		{ip: "224.0.0.1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := PublicIP(net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("PublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublicHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{host: "example.com", want: true},
+		{host: "hooks.example.com.", want: true},
+		{host: "93.184.216.34", want: true},
+		{host: "localhost", want: false},
+		{host: "LOCALHOST.", want: false},
+		{host: "api.localhost", want: false},
+		{host: "127.0.0.1", want: false},
+		{host: "169.254.169.254", want: false},
+		{host: "::1", want: false},
+		{host: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := PublicHost(tt.host); got != tt.want {
+				t.Errorf("PublicHost(%q) = %v, want %v", tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendRefusesPrivateAddresses(t *testing.T) {
+	hit := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer server.Close()
+
+	_, err := Send(context.Background(), server.URL, "secret", "webhook.test", 1, []byte("{}"))
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("Send() error = %v, want ErrPrivateAddress", err)
+	}
+	if hit {
+		t.Errorf("the local endpoint was called")
+	}
+}
+
+// let the client reach the test servers on the loopback address, the other settings are kept
+func allowLoopback(t *testing.T) {
+	saved := httpClient
+	httpClient = &http.Client{Timeout: saved.Timeout, CheckRedirect: saved.CheckRedirect}
+	t.Cleanup(func() { httpClient = saved })
+}
+
+func TestSend(t *testing.T) {
+	allowLoopback(t)
+
+	var redirected bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hook", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get("X-VidVerse-Timestamp"), 10, 64)
+		if r.Method != http.MethodPost || r.Header.Get("X-VidVerse-Signature") != Sign("secret", timestamp, body) ||
+			r.Header.Get("X-VidVerse-Event") != "webhook.test" || r.Header.Get("X-VidVerse-Delivery") != "7" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/failing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/internal", http.StatusFound)
+	})
+	mux.HandleFunc("/internal", func(w http.ResponseWriter, r *http.Request) {
+		redirected = true
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantErr    bool
+	}{
+		{name: "signed delivery", path: "/hook", wantStatus: http.StatusOK},
+		{name: "endpoint error", path: "/failing", wantStatus: http.StatusInternalServerError, wantErr: true},
+		{name: "redirect is not followed", path: "/redirect", wantStatus: http.StatusFound, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Send(context.Background(), server.URL+tt.path, "secret", "webhook.test", 7, []byte(`{"id":"1"}`))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("Send() status = %d, want %d", result.Status, tt.wantStatus)
+			}
+		})
+	}
+
+	if redirected {
+		t.Errorf("the redirect was followed")
+	}
+}
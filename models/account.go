@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// states of a data export
+const (
+	ExportPending    = "pending"
+	ExportProcessing = "processing"
+	ExportReady      = "ready"
+	ExportFailed     = "failed"
+)
+
+// DataExport is a ZIP of everything a user has on the site, built in the background
+type DataExport struct {
+	CustomModel
+	UserID      uint       `gorm:"not null;index" json:"-"`
+	Status      string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	FilePath    string     `gorm:"type:varchar(255)" json:"-"`
+	Error       string     `gorm:"type:varchar(255)" json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// UserData is the content of a data export, every field is saved as its own JSON file
+type UserData struct {
+	Profile       *User
+	Channels      []map[string]interface{}
+	Videos        []map[string]interface{}
+	Comments      []map[string]interface{}
+	Likes         []map[string]interface{}
+	Subscriptions []map[string]interface{}
+	Notifications []map[string]interface{}
+}
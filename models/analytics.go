@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// what happened in an analytics event
+const (
+	EventView        = "view"
+	EventLike        = "like"
+	EventUnlike      = "unlike"
+	EventComment     = "comment"
+	EventSubscribe   = "subscribe"
+	EventUnsubscribe = "unsubscribe"
+	EventWatch       = "watch"
+)
+
+type AnalyticsEvent struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Type         string    `gorm:"type:varchar(20);not null" json:"type"`
+	ChannelID    uint      `gorm:"not null;index:idx_analytics_events_channel_time,priority:1" json:"channel_id"`
+	VideoID      uint      `gorm:"not null;default:0" json:"video_id"`
+	UserID       uint      `gorm:"not null;default:0" json:"user_id"`
+	WatchSeconds float64   `gorm:"not null;default:0" json:"watch_seconds"`
+	CreatedAt    time.Time `gorm:"index;index:idx_analytics_events_channel_time,priority:2" json:"created_at"`
+}
+
+// ChannelDailyStat is the rollup of the events of a video in a day, subscriptions are kept with the video ID 0
+type ChannelDailyStat struct {
+	ChannelID         uint      `gorm:"primaryKey;autoIncrement:false" json:"channel_id"`
+	VideoID           uint      `gorm:"primaryKey;autoIncrement:false" json:"video_id"`
+	Day               time.Time `gorm:"primaryKey;type:date" json:"day"`
+	Views             int64     `gorm:"not null;default:0" json:"views"`
+	Likes             int64     `gorm:"not null;default:0" json:"likes"`
+	Comments          int64     `gorm:"not null;default:0" json:"comments"`
+	SubscribersGained int64     `gorm:"not null;default:0" json:"subscribers_gained"`
+	SubscribersLost   int64     `gorm:"not null;default:0" json:"subscribers_lost"`
+	WatchSeconds      float64   `gorm:"not null;default:0" json:"watch_seconds"`
+	UpdatedAt         time.Time `json:"-"`
+}
+
+type DailyStatDTO struct {
+	Day               time.Time `json:"day"`
+	Views             int64     `json:"views"`
+	Likes             int64     `json:"likes"`
+	Comments          int64     `json:"comments"`
+	SubscribersGained int64     `json:"subscribers_gained"`
+	SubscribersLost   int64     `json:"subscribers_lost"`
+	WatchSeconds      float64   `json:"watch_seconds"`
+}
+
+type VideoStatDTO struct {
+	VideoID      uint    `json:"video_id"`
+	Title        string  `json:"title"`
+	Thumb        string  `json:"thumb"`
+	Views        int64   `json:"views"`
+	Likes        int64   `json:"likes"`
+	Comments     int64   `json:"comments"`
+	WatchSeconds float64 `json:"watch_seconds"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// what a personal API key is allowed to do
+const (
+	ScopeRead          = "read"
+	ScopeUpload        = "upload"
+	ScopeManageChannel = "manage_channel"
+	ScopeModerate      = "moderate"
+)
+
+// the scopes an API key can be created with
+var APIKeyScopes = []string{
+	ScopeRead,
+	ScopeUpload,
+	ScopeManageChannel,
+	ScopeModerate,
+}
+
+// the first characters of every API key, they tell a key from a login token
+const APIKeyPrefix = "vv_"
+
+// APIKey lets scripts call the API as a user, only the hash of the key is stored
+type APIKey struct {
+	CustomModel
+	UserID     uint       `gorm:"not null;index" json:"-"`
+	Name       string     `gorm:"type:varchar(100);not null" json:"name"`
+	Prefix     string     `gorm:"type:varchar(20);not null" json:"prefix"`
+	KeyHash    string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	Scopes     string     `gorm:"type:varchar(255);not null" json:"-"`
+	ScopeList  []string   `gorm:"-" json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// HasScope check the key has a scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range strings.Split(k.Scopes, ",") {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,19 @@
+package models
+
+// the cover of a channel without an uploaded cover
+const DefaultChannelCover = "https://res.cloudinary.com/dog87elav/image/upload/v1703925125/vidverse/uploads/default-images/default_cover_ynckzo.jpg"
+
+// corners of the player the watermark of a channel can be shown in
+const (
+	WatermarkTopLeft     = "top-left"
+	WatermarkTopRight    = "top-right"
+	WatermarkBottomLeft  = "bottom-left"
+	WatermarkBottomRight = "bottom-right"
+)
+
+var WatermarkPositions = []string{
+	WatermarkTopLeft,
+	WatermarkTopRight,
+	WatermarkBottomLeft,
+	WatermarkBottomRight,
+}
@@ -0,0 +1,30 @@
+package models
+
+// changes that can be applied to many videos at once
+const (
+	BulkActionVisibility = "visibility"
+	BulkActionMove       = "move"
+	BulkActionAddTags    = "add_tags"
+	BulkActionDelete     = "delete"
+)
+
+var BulkVideoActions = []string{
+	BulkActionVisibility,
+	BulkActionMove,
+	BulkActionAddTags,
+	BulkActionDelete,
+}
+
+type BulkVideoAction struct {
+	Action     string
+	Visibility string
+	ChannelID  uint
+	Tags       []Tag
+}
+
+type BulkVideoResult struct {
+	VideoID   uint   `json:"video_id"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+	Published bool   `json:"-"`
+}
@@ -0,0 +1,21 @@
+package models
+
+// VideoCategories is the fixed taxonomy a video can be filed under
+var VideoCategories = []string{
+	"film-animation",
+	"autos-vehicles",
+	"music",
+	"pets-animals",
+	"sports",
+	"travel-events",
+	"gaming",
+	"people-blogs",
+	"comedy",
+	"entertainment",
+	"news-politics",
+	"howto-style",
+	"education",
+	"science-technology",
+	"nonprofits-activism",
+	"other",
+}
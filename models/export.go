@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+type VideoExportRow struct {
+	ID         uint      `json:"id"`
+	Title      string    `json:"title"`
+	Visibility string    `json:"visibility"`
+	Views      int64     `json:"views"`
+	Likes      int64     `json:"likes"`
+	Comments   int64     `json:"comments"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type SubscriberExportRow struct {
+	Day              time.Time `json:"day"`
+	NewSubscribers   int64     `json:"new_subscribers"`
+	TotalSubscribers int64     `json:"total_subscribers"`
+}
+
+type CommentExportRow struct {
+	ID         uint      `json:"id"`
+	VideoID    uint      `json:"video_id"`
+	VideoTitle string    `json:"video_title"`
+	UserName   string    `json:"user_name"`
+	Text       string    `json:"text"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -17,11 +17,20 @@ type User struct {
 	CustomModel
 	Name string `gorm:"type:varchar(100);not null" json:"name"`
 	// UserName string `gorm:"type:varchar(100);unique;not null" json:"username"`
-	Email    string `gorm:"type:varchar(255);unique;not null" json:"email"`
-	Password string `gorm:"type:varchar(255);not null" json:"-"`
-	Avatar   string `gorm:"type:varchar(255);not null;default:'https://upload.wikimedia.org/wikipedia/commons/5/59/User-avatar.svg'" json:"avatar"`
-	IsActive bool   `gorm:"type:boolean;not null;default:false" json:"is_active"`
-	UserRole string `gorm:"type:varchar(150);not null;default:'user'" json:"user_role"`
+	Email          string `gorm:"type:varchar(255);unique;not null" json:"email"`
+	Password       string `gorm:"type:varchar(255);not null" json:"-"`
+	Avatar         string `gorm:"type:varchar(255);not null;default:'https://upload.wikimedia.org/wikipedia/commons/5/59/User-avatar.svg'" json:"avatar"`
+	AvatarPublicID string `gorm:"type:varchar(255)" json:"-"`
+	IsActive       bool   `gorm:"type:boolean;not null;default:false" json:"is_active"`
+	UserRole       string `gorm:"type:varchar(150);not null;default:'user'" json:"user_role"`
+	// the account is deleted for good after this time unless the deletion is cancelled
+	DeleteAfter *time.Time `gorm:"index" json:"delete_after,omitempty"`
+	// the secret of the authenticator app, it is kept while the enrolment waits for a first code
+	TOTPSecret   string `gorm:"type:varchar(64)" json:"-"`
+	TOTPEnabled  bool   `gorm:"type:boolean;not null;default:false" json:"totp_enabled"`
+	TOTPLastStep int64  `gorm:"not null;default:0" json:"-"`
+	// the user proved to own the email, by a verification link or a login with a provider that verified it
+	EmailVerified bool `gorm:"type:boolean;not null;default:false" json:"email_verified"`
 }
 
 type Token struct {
@@ -44,24 +53,38 @@ type UserPayload struct {
 
 type Channel struct {
 	CustomModel
-	Title         string         `gorm:"type:varchar(100)" json:"title"`
-	Description   string         `gorm:"type:text;size:500" json:"description"`
-	Logo          string         `gorm:"type:varchar(255);" json:"logo"`
-	UserID        uint           `json:"user_id"`
-	LogoPublicID  string         `gorm:"type:varchar(255);not null" json:"-"`
-	Cover         string         `gorm:"type:varchar(255);not null;default:'https://res.cloudinary.com/dog87elav/image/upload/v1703925125/vidverse/uploads/default-images/default_cover_ynckzo.jpg'" json:"cover"`
-	CoverPublicID string         `gorm:"type:varchar(255)" json:"-"`
-	User          User           `gorm:"foreignKey:UserID" json:"user"`
-	Videos        []Video        `gorm:"foreignKey:ChannelID" json:"videos"`
-	Subscribers   []Subscription `json:"subscribers,omitempty"`
-	Subscriptions int64          `json:"subscriptions,omitempty"`
-	IsSubscribed  bool           `json:"is_subscribed,omitempty"`
+	Title             string         `gorm:"type:varchar(100)" json:"title"`
+	Handle            string         `gorm:"type:varchar(30);uniqueIndex" json:"handle"`
+	HandleChangedAt   *time.Time     `json:"-"`
+	Description       string         `gorm:"type:text;size:500" json:"description"`
+	Logo              string         `gorm:"type:varchar(255);" json:"logo"`
+	UserID            uint           `json:"user_id"`
+	LogoPublicID      string         `gorm:"type:varchar(255);not null" json:"-"`
+	Cover             string         `gorm:"type:varchar(255);not null;default:'https://res.cloudinary.com/dog87elav/image/upload/v1703925125/vidverse/uploads/default-images/default_cover_ynckzo.jpg'" json:"cover"`
+	CoverPublicID     string         `gorm:"type:varchar(255)" json:"-"`
+	Watermark         string         `gorm:"type:varchar(255)" json:"watermark"`
+	WatermarkPublicID string         `gorm:"type:varchar(255)" json:"-"`
+	WatermarkPosition string         `gorm:"type:varchar(20);not null;default:'bottom-right'" json:"watermark_position"`
+	User              User           `gorm:"foreignKey:UserID" json:"user"`
+	Videos            []Video        `gorm:"foreignKey:ChannelID" json:"videos"`
+	Subscribers       []Subscription `json:"subscribers,omitempty"`
+	Subscriptions     int64          `json:"subscriptions,omitempty"`
+	IsSubscribed      bool           `json:"is_subscribed,omitempty"`
 }
 
 type Subscription struct {
 	CustomModel
-	UserID    uint `gorm:"foreignKey:UserID" json:"user_id"`
-	ChannelID uint `gorm:"foreignKey:ChannelID" json:"channel_id"`
+	UserID     uint       `gorm:"foreignKey:UserID" json:"user_id"`
+	ChannelID  uint       `gorm:"foreignKey:ChannelID" json:"channel_id"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+}
+
+type SubscribedChannelDTO struct {
+	ID           uint      `json:"id"`
+	Title        string    `json:"title"`
+	Logo         string    `json:"logo"`
+	UnseenVideos int64     `json:"unseen_videos"`
+	SubscribedAt time.Time `json:"subscribed_at"`
 }
 
 type ChannelPayload struct {
@@ -77,30 +100,159 @@ type ChannelPayload struct {
 
 type Video struct {
 	CustomModel
-	Title         string    `gorm:"type:varchar(255);not null" json:"title,omitempty" binding:"required,min=2,max=255"`
-	Description   string    `gorm:"type:text;size:500;not null" json:"description,omitempty" binding:"required,min=2,max=500"`
-	PublicID      string    `gorm:"type:varchar(255);not null" json:"-"`
-	SecureURL     string    `gorm:"type:varchar(255);not null" json:"secure_url,omitempty"`
-	ChannelID     uint      `json:"channel_id,omitempty"`
-	Channel       Channel   `gorm:"foreignKey:ChannelID" json:"channel,omitempty"`
-	Thumb         string    `gorm:"type:varchar(255)" json:"tumb,omitempty"`
-	Likes         []Like    `json:"likes,omitempty"`
-	Comments      []Comment `json:"comments,omitempty"`
-	Views         int64     `gorm:"type:bigint;not null;default:0" json:"views,omitempty"`
-	ThumbPublicID string    `gorm:"type:varchar(255)" json:"-"`
+	Title         string     `gorm:"type:varchar(255);not null" json:"title,omitempty" binding:"required,min=2,max=255"`
+	Description   string     `gorm:"type:text;size:500;not null" json:"description,omitempty" binding:"required,min=2,max=500"`
+	PublicID      string     `gorm:"type:varchar(255);not null" json:"-"`
+	SecureURL     string     `gorm:"type:varchar(255);not null" json:"secure_url,omitempty"`
+	ChannelID     uint       `json:"channel_id,omitempty"`
+	Channel       Channel    `gorm:"foreignKey:ChannelID" json:"channel,omitempty"`
+	Thumb         string     `gorm:"type:varchar(255)" json:"tumb,omitempty"`
+	ThumbSmall    string     `gorm:"type:varchar(255)" json:"thumb_small,omitempty"`
+	ThumbLarge    string     `gorm:"type:varchar(255)" json:"thumb_large,omitempty"`
+	Likes         []Like     `json:"likes,omitempty"`
+	Comments      []Comment  `json:"comments,omitempty"`
+	Views         int64      `gorm:"type:bigint;not null;default:0" json:"views,omitempty"`
+	ThumbPublicID string     `gorm:"type:varchar(255)" json:"-"`
+	Category      string     `gorm:"type:varchar(50);not null;default:'other';index" json:"category,omitempty"`
+	Tags          []Tag      `gorm:"many2many:video_tags;" json:"tags,omitempty"`
+	Chapters      []Chapter  `json:"chapters,omitempty"`
+	Visibility    string     `gorm:"type:varchar(20);not null;default:'public';index" json:"visibility,omitempty"`
+	PublishAt     *time.Time `gorm:"index" json:"publish_at,omitempty"`
+	Status        string     `gorm:"type:varchar(20);not null;default:'ready';index" json:"status,omitempty"`
+	StatusMessage string     `gorm:"type:varchar(255)" json:"status_message,omitempty"`
+	Duration      float64    `gorm:"not null;default:0" json:"duration,omitempty"`
+	Width         int        `gorm:"not null;default:0" json:"width,omitempty"`
+	Height        int        `gorm:"not null;default:0" json:"height,omitempty"`
+	Codec         string     `gorm:"type:varchar(50)" json:"codec,omitempty"`
+	Bitrate       int64      `gorm:"type:bigint;not null;default:0" json:"bitrate,omitempty"`
+	FileSize      int64      `gorm:"type:bigint;not null;default:0" json:"file_size,omitempty"`
+}
+
+type VideoThumbnail struct {
+	CustomModel
+	VideoID        uint    `gorm:"index;not null" json:"video_id"`
+	Timestamp      float64 `gorm:"not null;default:0" json:"timestamp"`
+	IsCustom       bool    `gorm:"type:boolean;not null;default:false" json:"is_custom"`
+	IsSelected     bool    `gorm:"type:boolean;not null;default:false" json:"is_selected"`
+	Small          string  `gorm:"type:varchar(255)" json:"small"`
+	Medium         string  `gorm:"type:varchar(255)" json:"medium"`
+	Large          string  `gorm:"type:varchar(255)" json:"large"`
+	SmallPublicID  string  `gorm:"type:varchar(255)" json:"-"`
+	MediumPublicID string  `gorm:"type:varchar(255)" json:"-"`
+	LargePublicID  string  `gorm:"type:varchar(255)" json:"-"`
+}
+
+type VideoPreview struct {
+	CustomModel
+	VideoID         uint    `gorm:"uniqueIndex;not null" json:"video_id"`
+	PreviewURL      string  `gorm:"type:varchar(255)" json:"preview_url"`
+	PreviewPublicID string  `gorm:"type:varchar(255)" json:"-"`
+	SpriteURL       string  `gorm:"type:varchar(255)" json:"sprite_url"`
+	SpritePublicID  string  `gorm:"type:varchar(255)" json:"-"`
+	SpriteInterval  float64 `gorm:"not null;default:0" json:"sprite_interval"`
+	ThumbnailTrack  string  `gorm:"type:text" json:"-"`
+}
+
+type Chapter struct {
+	CustomModel
+	VideoID   uint    `gorm:"not null;index" json:"-"`
+	Position  int     `gorm:"not null" json:"position"`
+	Title     string  `gorm:"type:varchar(100);not null" json:"title"`
+	StartTime float64 `gorm:"not null" json:"start_time"`
+	EndTime   float64 `gorm:"not null;default:0" json:"end_time"`
+}
+
+// ChannelHandleRedirect keeps an old handle pointing to its channel for a while after it was changed
+type ChannelHandleRedirect struct {
+	CustomModel
+	OldHandle string    `gorm:"type:varchar(30);uniqueIndex;not null" json:"old_handle"`
+	ChannelID uint      `gorm:"not null;index" json:"channel_id"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+}
+
+type Caption struct {
+	CustomModel
+	VideoID  uint   `gorm:"not null;uniqueIndex:idx_captions_video_language" json:"video_id"`
+	Language string `gorm:"type:varchar(16);not null;uniqueIndex:idx_captions_video_language" json:"language"`
+	Label    string `gorm:"type:varchar(50)" json:"label"`
+	Track    string `gorm:"type:text" json:"-"`
+	Text     string `gorm:"type:text" json:"-"`
+}
+
+type Tag struct {
+	CustomModel
+	Name   string  `gorm:"type:varchar(30);uniqueIndex;not null" json:"name"`
+	Videos []Video `gorm:"many2many:video_tags;" json:"-"`
 }
 
 type VideoDTO struct {
 	ID           uint      `json:"id"`
 	Title        string    `json:"title"`
 	Thumb        string    `json:"thumb"`
+	ThumbSmall   string    `json:"thumb_small"`
+	ThumbLarge   string    `json:"thumb_large"`
 	Views        int64     `json:"views"`
+	Duration     float64   `json:"duration"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	Codec        string    `json:"codec"`
+	FileSize     int64     `json:"file_size"`
 	ChannelID    uint      `json:"channel_id"`
 	ChannelTitle string    `json:"channel_title"`
 	ChannelLogo  string    `json:"channel_logo"`
 	CreatedAt    time.Time `json:"created_at"`
 }
 
+// ChannelVideoDTO is a video of a channel as seen by its team, whatever its visibility or status
+type ChannelVideoDTO struct {
+	ID            uint       `json:"id"`
+	Title         string     `json:"title"`
+	Thumb         string     `json:"thumb"`
+	ThumbSmall    string     `json:"thumb_small"`
+	Views         int64      `json:"views"`
+	Duration      float64    `json:"duration"`
+	Visibility    string     `json:"visibility"`
+	PublishAt     *time.Time `json:"publish_at"`
+	Status        string     `json:"status"`
+	StatusMessage string     `json:"status_message"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type TrendingVideo struct {
+	VideoID       uint      `gorm:"primaryKey;autoIncrement:false" json:"video_id"`
+	Score         float64   `gorm:"not null;default:0;index" json:"score"`
+	ViewsSnapshot int64     `gorm:"not null;default:0" json:"-"`
+	ViewVelocity  float64   `gorm:"not null;default:0" json:"-"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type TrendingStat struct {
+	VideoID        uint
+	Views          int64
+	CreatedAt      time.Time
+	RecentViews    int64
+	RecentLikes    int64
+	RecentComments int64
+}
+
+type RelatedCandidate struct {
+	ID          uint
+	Title       string
+	Description string
+	ChannelID   uint
+	CoLikes     int64
+	CoWatches   int64
+	SharedTags  int64
+}
+
+type WatchHistory struct {
+	CustomModel
+	UserID  uint  `gorm:"index" json:"user_id"`
+	VideoID uint  `gorm:"index" json:"video_id"`
+	Video   Video `gorm:"foreignKey:VideoID" json:"-"`
+	User    User  `gorm:"foreignKey:UserID" json:"-"`
+}
+
 type Like struct {
 	CustomModel
 	UserID  uint  `json:"user_id"`
@@ -129,23 +281,29 @@ type CommentDTO struct {
 }
 
 type CustomChannel struct {
-	ID    uint   `json:"id"`
-	Title string `json:"title"`
-	Logo  string `json:"logo"`
+	ID     uint   `json:"id"`
+	Title  string `json:"title"`
+	Handle string `json:"handle"`
+	Logo   string `json:"logo"`
 }
 
 type CustomChannelDTO struct {
-	ID              uint   `json:"id,omitempty"`
-	Title           string `json:"title,omitempty"`
-	Logo            string `json:"logo,omitempty"`
-	Description     string `json:"description,omitempty"`
-	TotalSubscriber int64  `json:"total_subscriber,omitempty"`
-	TotalVideo      int64  `json:"total_video,omitempty"`
-	UserID          uint   `json:"user_id,omitempty"`
-	LogoPublicID    string `json:"-"`
-	Cover           string `json:"cover,omitempty"`
-	CoverPublicID   string `json:"-"`
-	IsSubscribed    bool   `json:"is_subscribed,omitempty"`
+	ID                uint       `json:"id,omitempty"`
+	Title             string     `json:"title,omitempty"`
+	Handle            string     `json:"handle,omitempty"`
+	HandleChangedAt   *time.Time `json:"-"`
+	Logo              string     `json:"logo,omitempty"`
+	Description       string     `json:"description,omitempty"`
+	TotalSubscriber   int64      `json:"total_subscriber,omitempty"`
+	TotalVideo        int64      `json:"total_video,omitempty"`
+	UserID            uint       `json:"user_id,omitempty"`
+	LogoPublicID      string     `json:"-"`
+	Cover             string     `json:"cover,omitempty"`
+	CoverPublicID     string     `json:"-"`
+	Watermark         string     `json:"watermark,omitempty"`
+	WatermarkPublicID string     `json:"-"`
+	WatermarkPosition string     `json:"watermark_position,omitempty"`
+	IsSubscribed      bool       `json:"is_subscribed,omitempty"`
 }
 
 type Notification struct {
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// OIDCState is a login with an OpenID Connect provider waiting for its callback
+type OIDCState struct {
+	CustomModel
+	State     string    `gorm:"type:varchar(255);uniqueIndex;not null" json:"-"`
+	Provider  string    `gorm:"type:varchar(50);not null" json:"provider"`
+	Verifier  string    `gorm:"type:varchar(255);not null" json:"-"`
+	Nonce     string    `gorm:"type:varchar(255);not null" json:"-"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+}
+
+// UserIdentity links an account of an OpenID Connect provider to a user
+type UserIdentity struct {
+	CustomModel
+	UserID   uint   `gorm:"not null;index" json:"user_id"`
+	Provider string `gorm:"type:varchar(50);not null;uniqueIndex:idx_user_identities_provider_subject" json:"provider"`
+	Subject  string `gorm:"type:varchar(255);not null;uniqueIndex:idx_user_identities_provider_subject" json:"-"`
+	Email    string `gorm:"type:varchar(255)" json:"email"`
+}
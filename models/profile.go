@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// the avatar of a user without an uploaded avatar
+const DefaultUserAvatar = "https://upload.wikimedia.org/wikipedia/commons/5/59/User-avatar.svg"
+
+// EmailChange is a new email address of a user waiting to be verified
+type EmailChange struct {
+	CustomModel
+	UserID    uint      `gorm:"not null;uniqueIndex" json:"user_id"`
+	NewEmail  string    `gorm:"type:varchar(255);not null" json:"new_email"`
+	Token     string    `gorm:"type:varchar(255);uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+// processing state of an uploaded video
+const (
+	StatusUploading  = "uploading"
+	StatusProcessing = "processing"
+	StatusReady      = "ready"
+	StatusFailed     = "failed"
+)
+
+var VideoStatuses = []string{
+	StatusUploading,
+	StatusProcessing,
+	StatusReady,
+	StatusFailed,
+}
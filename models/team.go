@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+// roles of the members of a channel, the owner is the user of the channel
+const (
+	RoleOwner           = "owner"
+	RoleManager         = "manager"
+	RoleEditor          = "editor"
+	RoleAnalyticsViewer = "analytics_viewer"
+)
+
+// the roles a member can be invited with
+var ChannelMemberRoles = []string{
+	RoleManager,
+	RoleEditor,
+	RoleAnalyticsViewer,
+}
+
+// what the members of a channel are allowed to do
+const (
+	PermManageChannel = "manage_channel"
+	PermManageMembers = "manage_members"
+	PermManageVideos  = "manage_videos"
+	PermViewAnalytics = "view_analytics"
+	PermDeleteChannel = "delete_channel"
+	PermManageHooks   = "manage_webhooks"
+)
+
+var rolePermissions = map[string][]string{
+	RoleOwner:           {PermManageChannel, PermManageMembers, PermManageVideos, PermViewAnalytics, PermDeleteChannel, PermManageHooks},
+	RoleManager:         {PermManageChannel, PermManageMembers, PermManageVideos, PermViewAnalytics},
+	RoleEditor:          {PermManageVideos},
+	RoleAnalyticsViewer: {PermViewAnalytics},
+}
+
+// RoleCan check a role of a channel has a permission
+func RoleCan(role, permission string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RolesWith list the member roles that have a permission, the owner is not a member role
+func RolesWith(permission string) []string {
+	var roles []string
+	for _, role := range ChannelMemberRoles {
+		if RoleCan(role, permission) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+type ChannelMember struct {
+	CustomModel
+	ChannelID uint   `gorm:"not null;uniqueIndex:idx_channel_members_channel_user" json:"channel_id"`
+	UserID    uint   `gorm:"not null;uniqueIndex:idx_channel_members_channel_user;index" json:"user_id"`
+	Role      string `gorm:"type:varchar(20);not null" json:"role"`
+}
+
+// ChannelInvite is sent by email, the invited user becomes a member when accepting it
+type ChannelInvite struct {
+	CustomModel
+	ChannelID  uint       `gorm:"not null;index" json:"channel_id"`
+	Email      string     `gorm:"type:varchar(255);not null" json:"email"`
+	Role       string     `gorm:"type:varchar(20);not null" json:"role"`
+	Token      string     `gorm:"type:varchar(255);uniqueIndex;not null" json:"-"`
+	InvitedBy  uint       `gorm:"not null" json:"invited_by"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+}
+
+type ChannelMemberDTO struct {
+	UserID   uint      `json:"user_id"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Avatar   string    `json:"avatar"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// TrashedChannelDTO is a deleted channel that can still be restored until PurgeAt
+type TrashedChannelDTO struct {
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	Handle    string    `json:"handle"`
+	Logo      string    `json:"logo"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+// TrashedVideoDTO is a deleted video that can still be restored until PurgeAt
+type TrashedVideoDTO struct {
+	ID           uint      `json:"id"`
+	Title        string    `json:"title"`
+	Thumb        string    `json:"thumb"`
+	ChannelID    uint      `json:"channel_id"`
+	ChannelTitle string    `json:"channel_title"`
+	DeletedAt    time.Time `json:"deleted_at"`
+	PurgeAt      time.Time `json:"purge_at"`
+}
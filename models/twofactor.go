@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// how many recovery codes a user gets when two-factor authentication is enabled
+const RecoveryCodeCount = 10
+
+// RecoveryCode logs a user in once when the authenticator app is lost, only its hash is stored
+type RecoveryCode struct {
+	CustomModel
+	UserID   uint       `gorm:"not null;index" json:"-"`
+	CodeHash string     `gorm:"type:varchar(64);not null;index" json:"-"`
+	UsedAt   *time.Time `json:"used_at,omitempty"`
+}
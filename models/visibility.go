@@ -0,0 +1,16 @@
+package models
+
+// who can see a video
+const (
+	VisibilityPublic   = "public"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPrivate  = "private"
+	VisibilityDraft    = "draft"
+)
+
+var VideoVisibilities = []string{
+	VisibilityPublic,
+	VisibilityUnlisted,
+	VisibilityPrivate,
+	VisibilityDraft,
+}
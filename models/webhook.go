@@ -0,0 +1,78 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// the events a webhook can be subscribed to
+const (
+	EventVideoPublished     = "video.published"
+	EventVideoStatusChanged = "video.status_changed"
+	EventCommentCreated     = "comment.created"
+	EventVideoLiked         = "video.liked"
+	EventChannelSubscribed  = "channel.subscribed"
+	EventWebhookTest        = "webhook.test"
+)
+
+// the events a webhook can be registered for, the test event is only sent on request
+var WebhookEvents = []string{
+	EventVideoPublished,
+	EventVideoStatusChanged,
+	EventCommentCreated,
+	EventVideoLiked,
+	EventChannelSubscribed,
+}
+
+// states of a webhook delivery, a sending delivery is claimed by a worker until its next attempt time
+const (
+	DeliveryPending   = "pending"
+	DeliverySending   = "sending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook is an endpoint of a channel that gets the events it is registered for
+type Webhook struct {
+	CustomModel
+	ChannelID uint     `gorm:"not null;index" json:"channel_id"`
+	UserID    uint     `gorm:"not null" json:"user_id"`
+	URL       string   `gorm:"type:varchar(500);not null" json:"url"`
+	Secret    string   `gorm:"type:varchar(100);not null" json:"-"`
+	Events    string   `gorm:"type:varchar(255);not null" json:"-"`
+	EventList []string `gorm:"-" json:"events"`
+	Active    bool     `gorm:"type:boolean;not null;default:true" json:"active"`
+}
+
+// HasEvent check the webhook is registered for an event
+func (w *Webhook) HasEvent(event string) bool {
+	for _, e := range strings.Split(w.Events, ",") {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event sent to a webhook, it is retried until it succeeds or runs out of attempts
+type WebhookDelivery struct {
+	CustomModel
+	WebhookID      uint       `gorm:"not null;index" json:"webhook_id"`
+	Event          string     `gorm:"type:varchar(50);not null" json:"event"`
+	Payload        string     `gorm:"type:text;not null" json:"payload"`
+	Status         string     `gorm:"type:varchar(20);not null;default:'pending';index:idx_webhook_deliveries_due,priority:1" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  *time.Time `gorm:"index:idx_webhook_deliveries_due,priority:2" json:"next_attempt_at,omitempty"`
+	ResponseStatus int        `gorm:"not null;default:0" json:"response_status"`
+	Error          string     `gorm:"type:varchar(255)" json:"error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+// WebhookPayload is the body every webhook receives
+type WebhookPayload struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	ChannelID uint        `json:"channel_id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
@@ -0,0 +1,220 @@
+package dbrepo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// Create a data export waiting to be built
+func (m *postgresDBRepo) CreateDataExport(export *models.DataExport) error {
+	err := m.DB.Create(export).Error
+	if err != nil {
+		return errors.New("failed to request the data export")
+	}
+
+	return nil
+}
+
+// Get the data exports of a user, the newest first
+func (m *postgresDBRepo) GetDataExports(userID uint) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := m.DB.Where("user_id = ?", userID).Order("created_at desc").Find(&exports).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return exports, nil
+}
+
+// Get a data export of a user
+func (m *postgresDBRepo) GetDataExport(userID, id uint) (*models.DataExport, error) {
+	var export models.DataExport
+	err := m.DB.Where("id = ? AND user_id = ?", id, userID).First(&export).Error
+	if err != nil {
+		return nil, errors.New("404 data export not found")
+	}
+
+	return &export, nil
+}
+
+// Get the data exports waiting to be built
+func (m *postgresDBRepo) GetPendingDataExports() ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := m.DB.Where("status = ?", models.ExportPending).Order("created_at asc").Find(&exports).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return exports, nil
+}
+
+// Queue again the data exports left half built by a previous run, they are built from scratch
+func (m *postgresDBRepo) ResetUnfinishedDataExports() error {
+	err := m.DB.Model(&models.DataExport{}).Where("status = ?", models.ExportProcessing).Update("status", models.ExportPending).Error
+	if err != nil {
+		return errors.New("failed to reset the data exports")
+	}
+
+	return nil
+}
+
+// Save the status and the file of a data export
+func (m *postgresDBRepo) UpdateDataExport(export *models.DataExport) error {
+	err := m.DB.Model(export).Select("status", "file_path", "error", "completed_at", "expires_at").Updates(export).Error
+	if err != nil {
+		return errors.New("failed to update the data export")
+	}
+
+	return nil
+}
+
+// Get the ready data exports whose download time is over
+func (m *postgresDBRepo) GetExpiredDataExports(now time.Time) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := m.DB.Where("expires_at < ?", now).Find(&exports).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return exports, nil
+}
+
+// Delete a data export for good
+func (m *postgresDBRepo) DeleteDataExport(id uint) error {
+	err := m.DB.Unscoped().Delete(&models.DataExport{}, id).Error
+	if err != nil {
+		return errors.New("failed to delete the data export")
+	}
+
+	return nil
+}
+
+// Collect everything a user has on the site for a data export
+func (m *postgresDBRepo) GetUserData(userID uint) (*models.UserData, error) {
+	user, err := m.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := models.UserData{Profile: user}
+
+	queries := []struct {
+		rows  *[]map[string]interface{}
+		table string
+		query string
+	}{
+		{&data.Channels, "channels", "SELECT id, title, handle, description, logo, cover, watermark, watermark_position, created_at, updated_at, deleted_at FROM channels WHERE user_id = ? ORDER BY id"},
+		{&data.Videos, "videos", "SELECT videos.id, videos.title, videos.description, videos.channel_id, videos.category, videos.visibility, videos.status, videos.views, videos.duration, videos.width, videos.height, videos.secure_url, videos.thumb, videos.publish_at, videos.created_at, videos.updated_at, videos.deleted_at FROM videos INNER JOIN channels ON channels.id = videos.channel_id WHERE channels.user_id = ? ORDER BY videos.id"},
+		{&data.Comments, "comments", "SELECT id, video_id, text, created_at, updated_at FROM comments WHERE user_id = ? AND deleted_at IS NULL ORDER BY id"},
+		{&data.Likes, "likes", "SELECT id, video_id, created_at FROM likes WHERE user_id = ? AND deleted_at IS NULL ORDER BY id"},
+		{&data.Subscriptions, "subscriptions", "SELECT subscriptions.id, subscriptions.channel_id, channels.title AS channel_title, subscriptions.created_at FROM subscriptions LEFT JOIN channels ON channels.id = subscriptions.channel_id WHERE subscriptions.user_id = ? AND subscriptions.deleted_at IS NULL ORDER BY subscriptions.id"},
+		{&data.Notifications, "notifications", "SELECT id, type, sender_name, video_id, channel_id, comment_id, like_id, is_read, created_at FROM notifications WHERE receiver_id = ? AND deleted_at IS NULL ORDER BY id"},
+	}
+
+	for _, q := range queries {
+		*q.rows = []map[string]interface{}{}
+		err = m.DB.Raw(q.query, userID).Scan(q.rows).Error
+		if err != nil {
+			return nil, errors.New("failed to collect the " + q.table)
+		}
+	}
+
+	return &data, nil
+}
+
+// Set or cancel the time an account is deleted for good
+func (m *postgresDBRepo) ScheduleAccountDeletion(userID uint, deleteAfter *time.Time) error {
+	err := m.DB.Model(&models.User{}).Where("id = ?", userID).Update("delete_after", deleteAfter).Error
+	if err != nil {
+		return errors.New("failed to update the account")
+	}
+
+	return nil
+}
+
+// Get the accounts whose deletion grace period is over
+func (m *postgresDBRepo) GetAccountsDueForDeletion(now time.Time) ([]models.User, error) {
+	var users []models.User
+	err := m.DB.Select("id, avatar_public_id").Where("delete_after IS NOT NULL AND delete_after < ?", now).Find(&users).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return users, nil
+}
+
+// Get the IDs of the channels a user owns, the ones in the trash included
+func (m *postgresDBRepo) GetOwnedChannelIDs(userID uint) ([]uint, error) {
+	var ids []uint
+	err := m.DB.Unscoped().Model(&models.Channel{}).Where("user_id = ?", userID).Pluck("id", &ids).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return ids, nil
+}
+
+// Delete an account for good, the comments stay without their author and the rest of the user's rows are removed
+func (m *postgresDBRepo) DeleteUserAccount(userID uint) error {
+	var user models.User
+	err := m.DB.Select("id, email").First(&user, userID).Error
+	if err != nil {
+		return errors.New("404 user not found")
+	}
+
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// the comments stay in the conversations without the name of their author
+	err = tx.Unscoped().Model(&models.Comment{}).Where("user_id = ?", userID).Update("user_id", nil).Error
+	if err == nil {
+		err = tx.Model(&models.AnalyticsEvent{}).Where("user_id = ?", userID).Update("user_id", 0).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to delete the account")
+	}
+
+	for _, model := range []interface{}{&models.Like{}, &models.Subscription{}, &models.WatchHistory{}, &models.Token{}, &models.EmailChange{}, &models.ChannelMember{}, &models.DataExport{}, &models.RecoveryCode{}, &models.UserIdentity{}, &models.APIKey{}} {
+		err = tx.Unscoped().Where("user_id = ?", userID).Delete(model).Error
+		if err != nil {
+			tx.Rollback()
+			return errors.New("failed to delete the account")
+		}
+	}
+
+	err = tx.Unscoped().Where("lower(email) = lower(?)", user.Email).Delete(&models.ChannelInvite{}).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to delete the account")
+	}
+
+	err = tx.Unscoped().Delete(&user).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to delete the account")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.New("failed to delete the account")
+	}
+
+	// the notifications sent to or by the user go once the account is gone
+	err = m.DeleteNotificationBySenderID(userID)
+	if err == nil {
+		err = m.DeleteNotificationByReceiverID(userID)
+	}
+	if err != nil {
+		return errors.New("failed to delete the notifications of the account")
+	}
+
+	return nil
+}
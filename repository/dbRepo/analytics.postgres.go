@@ -0,0 +1,92 @@
+package dbrepo
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// Save an analytics event
+func (m *postgresDBRepo) RecordAnalyticsEvent(event *models.AnalyticsEvent) error {
+	result := m.DB.Create(event)
+	if result.Error != nil {
+		return errors.New("failed to record the analytics event")
+	}
+
+	return nil
+}
+
+// Roll the analytics events up into daily stats, the last rolled day is counted again as it may have been partial
+func (m *postgresDBRepo) RollupAnalytics() error {
+	var lastDay sql.NullTime
+	err := m.DB.Model(&models.ChannelDailyStat{}).Select("max(day)").Row().Scan(&lastDay)
+	if err != nil {
+		return errors.New("failed to roll up the analytics")
+	}
+
+	since := lastDay.Time
+
+	err = m.DB.Exec(`INSERT INTO channel_daily_stats (channel_id, video_id, day, views, likes, comments, subscribers_gained, subscribers_lost, watch_seconds, updated_at)
+		SELECT channel_id, video_id, date(created_at),
+			count(*) FILTER (WHERE type = @view),
+			count(*) FILTER (WHERE type = @like) - count(*) FILTER (WHERE type = @unlike),
+			count(*) FILTER (WHERE type = @comment),
+			count(*) FILTER (WHERE type = @subscribe),
+			count(*) FILTER (WHERE type = @unsubscribe),
+			COALESCE(sum(watch_seconds), 0),
+			now()
+		FROM analytics_events
+		WHERE created_at >= @since
+		GROUP BY channel_id, video_id, date(created_at)
+		ON CONFLICT (channel_id, video_id, day) DO UPDATE SET
+			views = excluded.views, likes = excluded.likes, comments = excluded.comments,
+			subscribers_gained = excluded.subscribers_gained, subscribers_lost = excluded.subscribers_lost,
+			watch_seconds = excluded.watch_seconds, updated_at = excluded.updated_at`,
+		map[string]interface{}{
+			"view": models.EventView, "like": models.EventLike, "unlike": models.EventUnlike, "comment": models.EventComment,
+			"subscribe": models.EventSubscribe, "unsubscribe": models.EventUnsubscribe, "since": since,
+		}).Error
+	if err != nil {
+		return errors.New("failed to roll up the analytics")
+	}
+
+	return nil
+}
+
+// Get the stats of a channel per day, only of a single video when videoID is set
+func (m *postgresDBRepo) GetChannelDailyStats(channelID, videoID uint, from, to time.Time) ([]models.DailyStatDTO, error) {
+	var stats []models.DailyStatDTO
+
+	query := m.DB.Model(&models.ChannelDailyStat{}).Select("day, sum(views) as views, sum(likes) as likes, sum(comments) as comments, sum(subscribers_gained) as subscribers_gained, sum(subscribers_lost) as subscribers_lost, sum(watch_seconds) as watch_seconds").
+		Where("channel_id = ? AND day BETWEEN ? AND ?", channelID, from, to)
+	if videoID > 0 {
+		query = query.Where("video_id = ?", videoID)
+	}
+
+	err := query.Group("day").Order("day asc").Find(&stats).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return stats, nil
+}
+
+// Get the most viewed videos of a channel in a date range
+func (m *postgresDBRepo) GetChannelTopVideos(channelID uint, from, to time.Time, limit int) ([]models.VideoStatDTO, error) {
+	var videos []models.VideoStatDTO
+
+	err := m.DB.Table("channel_daily_stats").Select("videos.id as video_id, videos.title, videos.thumb, sum(channel_daily_stats.views) as views, sum(channel_daily_stats.likes) as likes, sum(channel_daily_stats.comments) as comments, sum(channel_daily_stats.watch_seconds) as watch_seconds").
+		Joins("inner join videos on videos.id = channel_daily_stats.video_id AND videos.deleted_at IS NULL").
+		Where("channel_daily_stats.channel_id = ? AND channel_daily_stats.day BETWEEN ? AND ?", channelID, from, to).
+		Group("videos.id").
+		Order("views desc, watch_seconds desc").
+		Limit(limit).
+		Find(&videos).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return videos, nil
+}
@@ -0,0 +1,73 @@
+package dbrepo
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// Create an API key of a user
+func (m *postgresDBRepo) CreateAPIKey(key *models.APIKey) error {
+	err := m.DB.Create(key).Error
+	if err != nil {
+		return errors.New("failed to create the API key")
+	}
+
+	key.ScopeList = strings.Split(key.Scopes, ",")
+	return nil
+}
+
+// Get the API keys of a user, the newest first
+func (m *postgresDBRepo) GetAPIKeys(userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := m.DB.Where("user_id = ?", userID).Order("created_at desc").Find(&keys).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	for i := range keys {
+		keys[i].ScopeList = strings.Split(keys[i].Scopes, ",")
+	}
+
+	return keys, nil
+}
+
+// Get an API key by the hash of the key
+func (m *postgresDBRepo) GetAPIKeyByHash(hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := m.DB.Where("key_hash = ?", hash).First(&key).Error
+	if err != nil {
+		return nil, errors.New("invalid API key")
+	}
+
+	key.ScopeList = strings.Split(key.Scopes, ",")
+	return &key, nil
+}
+
+// Save the time an API key was used, at most once a minute so busy scripts do not write on every request
+func (m *postgresDBRepo) TouchAPIKey(id uint) error {
+	now := time.Now()
+	err := m.DB.Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-time.Minute)).
+		UpdateColumn("last_used_at", now).Error
+	if err != nil {
+		return errors.New("failed to update the API key")
+	}
+
+	return nil
+}
+
+// Revoke an API key of a user
+func (m *postgresDBRepo) DeleteAPIKey(userID, id uint) error {
+	result := m.DB.Unscoped().Where("id = ? AND user_id = ?", id, userID).Delete(&models.APIKey{})
+	if result.Error != nil {
+		return errors.New("failed to revoke the API key")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("404 API key not found")
+	}
+
+	return nil
+}
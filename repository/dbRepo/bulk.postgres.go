@@ -0,0 +1,120 @@
+package dbrepo
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// Apply the same change to many videos in one transaction, the videos the user can not change are reported and skipped
+func (m *postgresDBRepo) BulkUpdateVideos(userID uint, isAdmin bool, videoIDs []uint, action *models.BulkVideoAction) ([]models.BulkVideoResult, error) {
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var videos []models.Video
+	err := tx.Preload("Channel").Where("id IN ?", videoIDs).Find(&videos).Error
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	// the user can change the videos of the channels they own or edit
+	managed := map[uint]bool{}
+	if !isAdmin {
+		channelIDs, err := memberChannelIDs(tx, userID, models.RolesWith(models.PermManageVideos))
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		for _, id := range channelIDs {
+			managed[id] = true
+		}
+	}
+
+	byID := make(map[uint]*models.Video, len(videos))
+	for i := range videos {
+		byID[videos[i].ID] = &videos[i]
+	}
+
+	results := make([]models.BulkVideoResult, 0, len(videoIDs))
+	for i, id := range videoIDs {
+		result := models.BulkVideoResult{VideoID: id}
+		video := byID[id]
+
+		result.Error = bulkSkipReason(video, isAdmin, managed)
+		if result.Error != "" {
+			results = append(results, result)
+			continue
+		}
+
+		// a failing video only rolls back its own changes
+		savePoint := fmt.Sprintf("bulk_video_%d", i)
+		err = tx.SavePoint(savePoint).Error
+		if err != nil {
+			tx.Rollback()
+			return nil, errors.New("failed to update the videos")
+		}
+
+		switch action.Action {
+		case models.BulkActionVisibility:
+			// changing the visibility manually cancels the schedule
+			err = tx.Model(video).Updates(map[string]interface{}{"visibility": action.Visibility, "publish_at": nil}).Error
+			result.Published = err == nil && bulkPublishes(video, action)
+		case models.BulkActionMove:
+			err = tx.Model(video).Update("channel_id", action.ChannelID).Error
+		case models.BulkActionAddTags:
+			err = tx.Model(video).Association("Tags").Append(action.Tags)
+		case models.BulkActionDelete:
+			// deleted videos go to the trash, the purge worker removes them for good
+			err = tx.Delete(video).Error
+		default:
+			err = errors.New("invalid action")
+		}
+
+		if err != nil {
+			// without the savepoint the transaction is aborted and nothing of the batch can be kept
+			if tx.RollbackTo(savePoint).Error != nil {
+				tx.Rollback()
+				return nil, errors.New("failed to update the videos")
+			}
+			result.Published = false
+			result.Error = "failed to update the video"
+		} else {
+			result.Success = true
+		}
+
+		results = append(results, result)
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return nil, errors.New("failed to update the videos")
+	}
+
+	return results, nil
+}
+
+// why a video of the batch is left unchanged, empty when the action can be applied to it
+func bulkSkipReason(video *models.Video, isAdmin bool, managed map[uint]bool) string {
+	switch {
+	case video == nil:
+		return "404 video not found"
+	case !isAdmin && !managed[video.ChannelID]:
+		return "Access denied! You are not allowed to update this video"
+	case video.Status == models.StatusUploading || video.Status == models.StatusProcessing:
+		return "The video is still being processed"
+	}
+	return ""
+}
+
+// whether the action makes a ready video public for the first time, its subscribers are notified then
+func bulkPublishes(video *models.Video, action *models.BulkVideoAction) bool {
+	return action.Action == models.BulkActionVisibility && video.Visibility != models.VisibilityPublic &&
+		action.Visibility == models.VisibilityPublic && video.Status == models.StatusReady
+}
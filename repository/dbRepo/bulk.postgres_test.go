@@ -0,0 +1,78 @@
+package dbrepo
+
+import (
+	"testing"
+
+	"github.com/raihan2bd/vidverse/models"
+)
+
+func TestBulkSkipReason(t *testing.T) {
+	managed := map[uint]bool{1: true}
+
+	tests := []struct {
+		name    string
+		video   *models.Video
+		isAdmin bool
+		want    string
+	}{
+		{name: "not found", video: nil, want: "404 video not found"},
+		{name: "managed channel", video: &models.Video{ChannelID: 1, Status: models.StatusReady}, want: ""},
+		{name: "other channel", video: &models.Video{ChannelID: 2, Status: models.StatusReady}, want: "Access denied! You are not allowed to update this video"},
+		{name: "admin changes any channel", video: &models.Video{ChannelID: 2, Status: models.StatusReady}, isAdmin: true, want: ""},
+		{name: "uploading", video: &models.Video{ChannelID: 1, Status: models.StatusUploading}, want: "The video is still being processed"},
+		{name: "processing", video: &models.Video{ChannelID: 1, Status: models.StatusProcessing}, isAdmin: true, want: "The video is still being processed"},
+		{name: "failed video can be changed", video: &models.Video{ChannelID: 1, Status: models.StatusFailed}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bulkSkipReason(tt.video, tt.isAdmin, managed); got != tt.want {
+				t.Errorf("bulkSkipReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBulkPublishes(t *testing.T) {
+	tests := []struct {
+		name   string
+		video  models.Video
+		action models.BulkVideoAction
+		want   bool
+	}{
+		{
+			name:   "private ready video made public",
+			video:  models.Video{Visibility: models.VisibilityPrivate, Status: models.StatusReady},
+			action: models.BulkVideoAction{Action: models.BulkActionVisibility, Visibility: models.VisibilityPublic},
+			want:   true,
+		},
+		{
+			name:   "already public",
+			video:  models.Video{Visibility: models.VisibilityPublic, Status: models.StatusReady},
+			action: models.BulkVideoAction{Action: models.BulkActionVisibility, Visibility: models.VisibilityPublic},
+		},
+		{
+			name:   "made unlisted",
+			video:  models.Video{Visibility: models.VisibilityDraft, Status: models.StatusReady},
+			action: models.BulkVideoAction{Action: models.BulkActionVisibility, Visibility: models.VisibilityUnlisted},
+		},
+		{
+			name:   "failed video made public",
+			video:  models.Video{Visibility: models.VisibilityPrivate, Status: models.StatusFailed},
+			action: models.BulkVideoAction{Action: models.BulkActionVisibility, Visibility: models.VisibilityPublic},
+		},
+		{
+			name:   "moved to another channel",
+			video:  models.Video{Visibility: models.VisibilityPrivate, Status: models.StatusReady},
+			action: models.BulkVideoAction{Action: models.BulkActionMove, ChannelID: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bulkPublishes(&tt.video, &tt.action); got != tt.want {
+				t.Errorf("bulkPublishes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
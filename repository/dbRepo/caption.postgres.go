@@ -0,0 +1,57 @@
+package dbrepo
+
+import (
+	"errors"
+
+	"github.com/raihan2bd/vidverse/models"
+	"gorm.io/gorm/clause"
+)
+
+// Save the caption track of a video, replacing the track already uploaded for the same language
+func (m *postgresDBRepo) SaveCaption(caption *models.Caption) (*models.Caption, error) {
+	err := m.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "video_id"}, {Name: "language"}},
+		DoUpdates: clause.AssignmentColumns([]string{"label", "track", "text", "updated_at"}),
+	}).Create(caption).Error
+	if err != nil {
+		return nil, errors.New("failed to save the caption")
+	}
+
+	return m.GetCaption(caption.VideoID, caption.Language)
+}
+
+// Get all the caption tracks of a video
+func (m *postgresDBRepo) GetCaptionsByVideoID(videoID uint) ([]models.Caption, error) {
+	var captions []models.Caption
+	err := m.DB.Where("video_id = ?", videoID).Order("language asc").Find(&captions).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return captions, nil
+}
+
+// Get the caption track of a video in a language
+func (m *postgresDBRepo) GetCaption(videoID uint, language string) (*models.Caption, error) {
+	var caption models.Caption
+	err := m.DB.Where("video_id = ? AND language = ?", videoID, language).First(&caption).Error
+	if err != nil {
+		return nil, errors.New("404 caption not found")
+	}
+
+	return &caption, nil
+}
+
+// Delete the caption track of a video in a language
+func (m *postgresDBRepo) DeleteCaption(videoID uint, language string) error {
+	result := m.DB.Unscoped().Where("video_id = ? AND language = ?", videoID, language).Delete(&models.Caption{})
+	if result.Error != nil {
+		return errors.New("failed to delete the caption")
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.New("404 caption not found")
+	}
+
+	return nil
+}
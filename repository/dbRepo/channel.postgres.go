@@ -12,10 +12,10 @@ import (
 func (m *postgresDBRepo) GetChannelByID(id int) (*models.CustomChannelDTO, error) {
 	var channel models.CustomChannelDTO
 
-	err := m.DB.Table("channels").Select("channels.id, channels.title, channels.logo, channels.description, count(DISTINCT videos.id) as total_videos, count(DISTINCT subscriptions.id) as total_subscribers, channels.user_id, channels.logo_public_id, channels.cover, channels.cover_public_id").
-		Joins("left join videos on videos.channel_id = channels.id").
+	err := m.DB.Table("channels").Select("channels.id, channels.title, channels.handle, channels.logo, channels.description, count(DISTINCT videos.id) as total_videos, count(DISTINCT subscriptions.id) as total_subscribers, channels.user_id, channels.logo_public_id, channels.cover, channels.cover_public_id, channels.watermark, channels.watermark_public_id, channels.watermark_position, channels.handle_changed_at").
+		Joins("left join videos on videos.channel_id = channels.id AND videos.deleted_at IS NULL").
 		Joins("left join subscriptions on subscriptions.channel_id = channels.id").
-		Where("channels.id = ?", id).
+		Where("channels.id = ? AND channels.deleted_at IS NULL", id).
 		Group("channels.id").
 		Find(&channel).Error
 
@@ -34,10 +34,11 @@ func (m *postgresDBRepo) CreateChannel(channel *models.Channel) (uint, error) {
 	return channel.ID, nil
 }
 
-// Get All the channels
+// Get All the channels the user can upload videos to
 func (m *postgresDBRepo) GetChannels(userID int) ([]models.CustomChannel, error) {
 	var channels []models.CustomChannel
-	err := m.DB.Table("channels").Select("channels.id, channels.title, channels.logo").Where("channels.user_id = ?", userID).
+	err := m.DB.Table("channels").Select("channels.id, channels.title, channels.handle, channels.logo").
+		Where("(channels.user_id = ? OR channels.id IN (?)) AND channels.deleted_at IS NULL", userID, m.DB.Table("channel_members").Select("channel_id").Where("user_id = ? AND role IN ? AND deleted_at IS NULL", userID, models.RolesWith(models.PermManageVideos))).
 		Find(&channels).Error
 	if err != nil {
 		return nil, errors.New("internal server error. Please try again")
@@ -46,14 +47,14 @@ func (m *postgresDBRepo) GetChannels(userID int) ([]models.CustomChannel, error)
 	return channels, nil
 }
 
-// Get channel by user id with details
+// Get the channels the user owns or is a member of with details
 func (m *postgresDBRepo) GetChannelsWithDetailsByUserID(userID uint) ([]models.CustomChannelDTO, error) {
 	var channels []models.CustomChannelDTO
 
-	err := m.DB.Table("channels").Select("channels.id, channels.title, channels.logo, channels.description, channels.cover, count(DISTINCT videos.id) as total_video, count(DISTINCT subscriptions.id) as total_subscriber, channels.user_id").
-		Joins("left join videos on videos.channel_id = channels.id").
+	err := m.DB.Table("channels").Select("channels.id, channels.title, channels.handle, channels.logo, channels.description, channels.cover, count(DISTINCT videos.id) as total_video, count(DISTINCT subscriptions.id) as total_subscriber, channels.user_id").
+		Joins("left join videos on videos.channel_id = channels.id AND videos.deleted_at IS NULL").
 		Joins("left join subscriptions on subscriptions.channel_id = channels.id").
-		Where("channels.user_id = ?", userID).
+		Where("(channels.user_id = ? OR channels.id IN (?)) AND channels.deleted_at IS NULL", userID, m.DB.Table("channel_members").Select("channel_id").Where("user_id = ? AND deleted_at IS NULL", userID)).
 		Group("channels.id").
 		Order("channels.created_at asc").
 		Find(&channels).Error
@@ -65,18 +66,18 @@ func (m *postgresDBRepo) GetChannelsWithDetailsByUserID(userID uint) ([]models.C
 	return channels, nil
 }
 
-// delete channel By Id
+// delete channel By Id for good, a channel in the trash is deleted as well
 func (m *postgresDBRepo) DeleteChannelByID(id int) *models.CustomError {
 	// get channel by id
 	var channel models.Channel
-	result := m.DB.Table("channels").Where("id = ?", id).First(&channel)
+	result := m.DB.Unscoped().Table("channels").Where("id = ?", id).First(&channel)
 
 	if result.Error != nil {
 		return &models.CustomError{Status: 404, Err: errors.New("the channel you want to delete is not found")}
 	}
 
 	var videos []models.Video
-	result = m.DB.Table("videos").Select("id, public_id, thumb_public_id").Where("channel_id = ?", id).Find(&videos)
+	result = m.DB.Unscoped().Table("videos").Select("id, public_id, thumb_public_id").Where("channel_id = ?", id).Find(&videos)
 
 	if result.Error != nil {
 		videos = nil
@@ -91,19 +92,49 @@ func (m *postgresDBRepo) DeleteChannelByID(id int) *models.CustomError {
 		}
 	}()
 
-	// delete all videos related to this channel
-	for _, video := range videos {
-		err := m.DeleteVideoModel(&video)
+	// delete all videos related to this channel, their files are removed once the channel is gone
+	videoImages := make([][]string, len(videos))
+	for i := range videos {
+		images, err := deleteVideo(tsx, &videos[i])
 		if err != nil {
+			tsx.Rollback()
 			log.Println(err)
+			return &models.CustomError{Status: 500, Err: errors.New("failed to delete the channel")}
 		}
+		videoImages[i] = images
+	}
+
+	// remove the team, the webhooks, the old handles and the analytics of the channel
+	err := tsx.Unscoped().Where("channel_id = ?", id).Delete(&models.ChannelMember{}).Error
+	if err == nil {
+		err = tsx.Unscoped().Where("channel_id = ?", id).Delete(&models.ChannelInvite{}).Error
+	}
+	if err == nil {
+		err = tsx.Unscoped().Where("webhook_id IN (?)", tsx.Model(&models.Webhook{}).Unscoped().Select("id").Where("channel_id = ?", id)).Delete(&models.WebhookDelivery{}).Error
+	}
+	if err == nil {
+		err = tsx.Unscoped().Where("channel_id = ?", id).Delete(&models.Webhook{}).Error
+	}
+	if err == nil {
+		err = tsx.Unscoped().Where("channel_id = ?", id).Delete(&models.ChannelHandleRedirect{}).Error
+	}
+	if err == nil {
+		err = tsx.Where("channel_id = ?", id).Delete(&models.AnalyticsEvent{}).Error
+	}
+	if err == nil {
+		err = tsx.Where("channel_id = ?", id).Delete(&models.ChannelDailyStat{}).Error
+	}
+	if err != nil {
+		tsx.Rollback()
+		return &models.CustomError{Status: 500, Err: errors.New("failed to delete the channel")}
 	}
 
 	// delete the channel
 	channelPublicID := channel.LogoPublicID
 	channelCoverPublicID := channel.CoverPublicID
+	channelWatermarkPublicID := channel.WatermarkPublicID
 
-	err := m.DB.Select(clause.Associations).Unscoped().Delete(&channel).Error
+	err = tsx.Select(clause.Associations).Unscoped().Delete(&channel).Error
 	if err != nil {
 		tsx.Rollback()
 		return &models.CustomError{Status: 500, Err: errors.New("failed to delete the channel")}
@@ -114,6 +145,10 @@ func (m *postgresDBRepo) DeleteChannelByID(id int) *models.CustomError {
 		return &models.CustomError{Status: 500, Err: errors.New("failed to delete the channel")}
 	}
 
+	for i, video := range videos {
+		go m.deleteVideoAssets(video.ID, video.PublicID, videoImages[i])
+	}
+
 	go func() {
 		// delete the logo from cloudinary
 		if channelPublicID != "" {
@@ -130,6 +165,14 @@ func (m *postgresDBRepo) DeleteChannelByID(id int) *models.CustomError {
 				log.Println(err)
 			}
 		}
+		// delete watermark from cloudinary
+		if channelWatermarkPublicID != "" {
+			err = m.DeleteImageFromCloudinary(channelWatermarkPublicID)
+			if err != nil {
+				log.Println(err)
+			}
+		}
+
 		// delete all notification related to this channel
 		err = m.DeleteNotificationsByChannelID(uint(id))
 		if err != nil {
@@ -150,10 +193,15 @@ func (m *postgresDBRepo) DeleteChannelByID(id int) *models.CustomError {
 func (m *postgresDBRepo) UpdateChannel(channel *models.CustomChannelDTO) error {
 
 	result := m.DB.Table("channels").Where("id = ?", channel.ID).Updates(map[string]interface{}{
-		"title":          channel.Title,
-		"description":    channel.Description,
-		"logo_public_id": channel.LogoPublicID,
-		"logo":           channel.Logo,
+		"title":               channel.Title,
+		"description":         channel.Description,
+		"logo_public_id":      channel.LogoPublicID,
+		"logo":                channel.Logo,
+		"cover":               channel.Cover,
+		"cover_public_id":     channel.CoverPublicID,
+		"watermark":           channel.Watermark,
+		"watermark_public_id": channel.WatermarkPublicID,
+		"watermark_position":  channel.WatermarkPosition,
 	})
 
 	if result.Error != nil {
@@ -167,8 +215,9 @@ func (m *postgresDBRepo) GetVideosByChannelIDWithPagination(channelID uint, page
 	var videos []models.VideoDTO
 	var count int64
 
-	err := m.DB.Table("videos").Select("videos.id, videos.title, videos.thumb, videos.views, videos.channel_id, videos.created_at, channels.title as channel_title, channels.logo as channel_logo").
+	err := m.DB.Table("videos").Select("videos.id, videos.title, videos.thumb, videos.thumb_small, videos.thumb_large, videos.views, videos.duration, videos.width, videos.height, videos.codec, videos.file_size, videos.channel_id, videos.created_at, channels.title as channel_title, channels.logo as channel_logo").
 		Joins("left join channels on channels.id = videos.channel_id").
+		Scopes(publicVideos).
 		Where("videos.channel_id = ?", channelID).
 		Order("videos.created_at desc").
 		Count(&count).
@@ -183,13 +232,39 @@ func (m *postgresDBRepo) GetVideosByChannelIDWithPagination(channelID uint, page
 	return videos, count, nil
 }
 
+// Get every video of a channel for its team, optionally filtered by visibility and status
+func (m *postgresDBRepo) GetManagedChannelVideos(channelID uint, visibility, status string, page, limit int) ([]models.ChannelVideoDTO, int64, error) {
+	var videos []models.ChannelVideoDTO
+	var count int64
+
+	query := m.DB.Table("videos").Select("videos.id, videos.title, videos.thumb, videos.thumb_small, videos.views, videos.duration, videos.visibility, videos.publish_at, videos.status, videos.status_message, videos.created_at").
+		Where("videos.channel_id = ? AND videos.deleted_at IS NULL", channelID)
+	if visibility != "" {
+		query = query.Where("videos.visibility = ?", visibility)
+	}
+	if status != "" {
+		query = query.Where("videos.status = ?", status)
+	}
+
+	err := query.Order("videos.created_at desc").
+		Count(&count).
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&videos).Error
+	if err != nil {
+		return nil, 0, errors.New("internal server error. Please try again")
+	}
+
+	return videos, count, nil
+}
+
 func (m *postgresDBRepo) GetChannelWithDetails(channelID, userID uint) (*models.CustomChannelDTO, error) {
 	var channel models.CustomChannelDTO
 
-	err := m.DB.Table("channels").Select("channels.id, channels.title, channels.logo, channels.description, channels.cover, count(DISTINCT videos.id) as total_video, count(DISTINCT subscriptions.id) as total_subscriber, channels.user_id").
-		Joins("left join videos on videos.channel_id = channels.id").
+	err := m.DB.Table("channels").Select("channels.id, channels.title, channels.handle, channels.logo, channels.description, channels.cover, count(DISTINCT videos.id) as total_video, count(DISTINCT subscriptions.id) as total_subscriber, channels.user_id").
+		Joins("left join videos on videos.channel_id = channels.id AND videos.deleted_at IS NULL").
 		Joins("left join subscriptions on subscriptions.channel_id = channels.id").
-		Where("channels.id = ?", channelID).
+		Where("channels.id = ? AND channels.deleted_at IS NULL", channelID).
 		Group("channels.id").
 		First(&channel).Error
 
@@ -0,0 +1,43 @@
+package dbrepo
+
+import (
+	"errors"
+
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// Replace the chapters of a video
+func (m *postgresDBRepo) ReplaceVideoChapters(videoID uint, chapters []models.Chapter) error {
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	err := tx.Unscoped().Where("video_id = ?", videoID).Delete(&models.Chapter{}).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to update the video chapters")
+	}
+
+	if len(chapters) > 0 {
+		for i := range chapters {
+			chapters[i].VideoID = videoID
+		}
+
+		err = tx.Create(&chapters).Error
+		if err != nil {
+			tx.Rollback()
+			return errors.New("failed to update the video chapters")
+		}
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.New("failed to update the video chapters")
+	}
+
+	return nil
+}
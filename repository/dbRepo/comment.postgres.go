@@ -12,7 +12,7 @@ func (m *postgresDBRepo) GetCommentsByVideoID(id, page, limit int) ([]models.Com
 	var count int64
 	offset := (page - 1) * limit
 
-	err := m.DB.Table("comments").Select("comments.id, comments.text, comments.video_id, users.id as user_id, users.name as user_name, users.avatar as user_avatar, comments.created_at").
+	err := m.DB.Table("comments").Select("comments.id, comments.text, comments.video_id, COALESCE(users.id, 0) as user_id, COALESCE(users.name, 'Deleted user') as user_name, COALESCE(users.avatar, ?) as user_avatar, comments.created_at", models.DefaultUserAvatar).
 		Joins("left join users on users.id = comments.user_id").
 		Where("comments.video_id = ?", id).
 		Count(&count).
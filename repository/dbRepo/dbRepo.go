@@ -2,6 +2,7 @@ package dbrepo
 
 import (
 	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/raihan2bd/vidverse/models"
 	"github.com/raihan2bd/vidverse/repository"
 	"gorm.io/gorm"
 )
@@ -17,3 +18,8 @@ func NewPostgresRepo(db *gorm.DB, cld *cloudinary.Cloudinary) repository.Databas
 		CLD: cld,
 	}
 }
+
+// scope the videos query to the processed videos everyone can see
+func publicVideos(db *gorm.DB) *gorm.DB {
+	return db.Where("videos.visibility = ? AND videos.status = ? AND videos.deleted_at IS NULL", models.VisibilityPublic, models.StatusReady)
+}
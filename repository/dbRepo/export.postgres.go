@@ -0,0 +1,71 @@
+package dbrepo
+
+import (
+	"errors"
+
+	"github.com/raihan2bd/vidverse/models"
+	"gorm.io/gorm"
+)
+
+// Stream the videos of a channel with their like and comment counts, oldest first
+func (m *postgresDBRepo) StreamChannelVideos(channelID uint, fn func(row *models.VideoExportRow) error) error {
+	query := m.DB.Table("videos").Select("videos.id, videos.title, videos.visibility, videos.views, videos.created_at, "+
+		"(SELECT count(*) FROM likes WHERE likes.video_id = videos.id AND likes.deleted_at IS NULL) as likes, "+
+		"(SELECT count(*) FROM comments WHERE comments.video_id = videos.id AND comments.deleted_at IS NULL) as comments").
+		Where("videos.channel_id = ? AND videos.deleted_at IS NULL", channelID).
+		Order("videos.created_at asc")
+
+	return streamRows(m.DB, query, fn)
+}
+
+// Stream the new and total subscribers of a channel per day
+func (m *postgresDBRepo) StreamChannelSubscribers(channelID uint, fn func(row *models.SubscriberExportRow) error) error {
+	daily := m.DB.Table("subscriptions").Select("date(subscriptions.created_at) as day, count(*) as new_subscribers").
+		Where("subscriptions.channel_id = ? AND subscriptions.deleted_at IS NULL", channelID).
+		Group("date(subscriptions.created_at)")
+
+	query := m.DB.Table("(?) as daily", daily).
+		Select("day, new_subscribers, sum(new_subscribers) OVER (ORDER BY day) as total_subscribers").
+		Order("day asc")
+
+	return streamRows(m.DB, query, fn)
+}
+
+// Stream the comments on the videos of a channel, oldest first
+func (m *postgresDBRepo) StreamChannelComments(channelID uint, fn func(row *models.CommentExportRow) error) error {
+	query := m.DB.Table("comments").Select("comments.id, comments.video_id, videos.title as video_title, COALESCE(users.name, 'Deleted user') as user_name, comments.text, comments.created_at").
+		Joins("inner join videos on videos.id = comments.video_id AND videos.deleted_at IS NULL").
+		Joins("left join users on users.id = comments.user_id").
+		Where("videos.channel_id = ? AND comments.deleted_at IS NULL", channelID).
+		Order("comments.created_at asc")
+
+	return streamRows(m.DB, query, fn)
+}
+
+// scan the rows of a query one at a time so the whole result never sits in memory
+func streamRows[T any](db *gorm.DB, query *gorm.DB, fn func(row *T) error) error {
+	rows, err := query.Rows()
+	if err != nil {
+		return errors.New("internal server error. Please try again")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row T
+		err = db.ScanRows(rows, &row)
+		if err != nil {
+			return errors.New("internal server error. Please try again")
+		}
+
+		err = fn(&row)
+		if err != nil {
+			return err
+		}
+	}
+
+	if rows.Err() != nil {
+		return errors.New("internal server error. Please try again")
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package dbrepo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/raihan2bd/vidverse/models"
+	"gorm.io/gorm/clause"
+)
+
+// Find the channel of a handle, an old handle still in its grace period also returns the current handle of the channel
+func (m *postgresDBRepo) FindChannelByHandle(handle string) (uint, string, error) {
+	var channel models.Channel
+	err := m.DB.Select("id, handle").Where("handle = ?", handle).First(&channel).Error
+	if err == nil {
+		return channel.ID, channel.Handle, nil
+	}
+
+	var redirect models.ChannelHandleRedirect
+	err = m.DB.Where("old_handle = ? AND expires_at > ?", handle, time.Now()).First(&redirect).Error
+	if err != nil {
+		return 0, "", errors.New("404 channel not found")
+	}
+
+	err = m.DB.Select("id, handle").First(&channel, redirect.ChannelID).Error
+	if err != nil {
+		return 0, "", errors.New("404 channel not found")
+	}
+
+	return channel.ID, channel.Handle, nil
+}
+
+// Check no other channel uses the handle or keeps it for a redirect
+func (m *postgresDBRepo) IsHandleAvailable(handle string, channelID uint) (bool, error) {
+	var count int64
+	// a channel in the trash keeps its handle until it is purged
+	err := m.DB.Unscoped().Model(&models.Channel{}).Where("handle = ? AND id <> ?", handle, channelID).Count(&count).Error
+	if err != nil {
+		return false, errors.New("internal server error. Please try again")
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	err = m.DB.Model(&models.ChannelHandleRedirect{}).Where("old_handle = ? AND channel_id <> ? AND expires_at > ?", handle, channelID, time.Now()).Count(&count).Error
+	if err != nil {
+		return false, errors.New("internal server error. Please try again")
+	}
+
+	return count == 0, nil
+}
+
+// Change the handle of a channel and keep the old one redirecting until redirectUntil
+func (m *postgresDBRepo) UpdateChannelHandle(channelID uint, oldHandle, newHandle string, redirectUntil time.Time) error {
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// the handle may be taken back from an expired redirect or from the channel's own redirect
+	err := tx.Unscoped().Where("old_handle = ?", newHandle).Delete(&models.ChannelHandleRedirect{}).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to update the channel handle")
+	}
+
+	err = tx.Model(&models.Channel{}).Where("id = ?", channelID).Updates(map[string]interface{}{"handle": newHandle, "handle_changed_at": time.Now()}).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to update the channel handle")
+	}
+
+	if oldHandle != "" {
+		redirect := models.ChannelHandleRedirect{OldHandle: oldHandle, ChannelID: channelID, ExpiresAt: redirectUntil}
+		err = tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "old_handle"}},
+			DoUpdates: clause.AssignmentColumns([]string{"channel_id", "expires_at", "updated_at"}),
+		}).Create(&redirect).Error
+		if err != nil {
+			tx.Rollback()
+			return errors.New("failed to update the channel handle")
+		}
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.New("failed to update the channel handle")
+	}
+
+	return nil
+}
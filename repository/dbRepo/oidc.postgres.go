@@ -0,0 +1,115 @@
+package dbrepo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/raihan2bd/vidverse/models"
+	"gorm.io/gorm"
+)
+
+// Save the state of a login with a provider, the expired ones are removed on the way
+func (m *postgresDBRepo) CreateOIDCState(state *models.OIDCState) error {
+	err := m.DB.Unscoped().Where("expires_at < ?", time.Now()).Delete(&models.OIDCState{}).Error
+	if err != nil {
+		return errors.New("internal server error. Please try again")
+	}
+
+	err = m.DB.Create(state).Error
+	if err != nil {
+		return errors.New("failed to start the login")
+	}
+
+	return nil
+}
+
+// Get the state of a login and remove it, a state is only used once
+func (m *postgresDBRepo) TakeOIDCState(state, provider string) (*models.OIDCState, error) {
+	var loginState models.OIDCState
+	err := m.DB.Where("state = ? AND provider = ? AND expires_at > ?", state, provider, time.Now()).First(&loginState).Error
+	if err != nil {
+		return nil, errors.New("the login is not found or has expired. Please try again")
+	}
+
+	result := m.DB.Unscoped().Delete(&loginState)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, errors.New("the login is not found or has expired. Please try again")
+	}
+
+	return &loginState, nil
+}
+
+// Get the identity of a provider account, nil when it is not linked to a user yet
+func (m *postgresDBRepo) GetUserIdentity(provider, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := m.DB.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return &identity, nil
+}
+
+// Link a provider account to a user, the email of the user is verified as the provider verified it
+func (m *postgresDBRepo) CreateUserIdentity(identity *models.UserIdentity) error {
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	err := tx.Create(identity).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to link the account")
+	}
+
+	err = tx.Model(&models.User{}).Where("id = ?", identity.UserID).Update("email_verified", true).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to link the account")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.New("failed to link the account")
+	}
+
+	return nil
+}
+
+// Create a user signing up with a provider together with its identity
+func (m *postgresDBRepo) CreateOIDCUser(user *models.User, identity *models.UserIdentity) error {
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	err := tx.Create(user).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to create the user. please try again later")
+	}
+
+	identity.UserID = user.ID
+	err = tx.Create(identity).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to create the user. please try again later")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.New("failed to create the user. please try again later")
+	}
+
+	return nil
+}
@@ -105,9 +105,10 @@ func (m *postgresDBRepo) GetAllVideos(page, limit int, searchQuery string) ([]mo
 
 	offset := (page - 1) * limit
 
-	err := m.DB.Table("videos").Select("videos.id, videos.title, videos.thumb, videos.views, videos.created_at, channels.id as channel_id, channels.title as channel_title, channels.logo as channel_logo").
+	err := m.DB.Table("videos").Select("videos.id, videos.title, videos.thumb, videos.thumb_small, videos.thumb_large, videos.views, videos.duration, videos.width, videos.height, videos.codec, videos.file_size, videos.created_at, channels.id as channel_id, channels.title as channel_title, channels.logo as channel_logo").
 		Joins("left join channels on channels.id = videos.channel_id").
-		Where("videos.title ILIKE ? OR videos.description ILIKE ? OR channels.title ILIKE ?", "%"+searchQuery+"%", "%"+searchQuery+"%", "%"+searchQuery+"%").
+		Scopes(publicVideos).
+		Where("videos.title ILIKE @search OR videos.description ILIKE @search OR channels.title ILIKE @search OR EXISTS (SELECT 1 FROM video_tags INNER JOIN tags ON tags.id = video_tags.tag_id WHERE video_tags.video_id = videos.id AND tags.name ILIKE @search) OR EXISTS (SELECT 1 FROM captions WHERE captions.video_id = videos.id AND captions.deleted_at IS NULL AND captions.text ILIKE @search)", map[string]interface{}{"search": "%" + searchQuery + "%"}).
 		Count(&count).
 		Offset(offset).Limit(limit).
 		Order("videos.created_at asc").
@@ -122,10 +123,11 @@ func (m *postgresDBRepo) GetAllVideos(page, limit int, searchQuery string) ([]mo
 // Get total videos count
 func (m *postgresDBRepo) GetTotalVideosCount(searchQuery string) (int64, error) {
 	var count int64
-	// return only videos count from the database with search query (videos title, description, channel title)
+	// return only videos count from the database with search query (videos title, description, channel title, tags, captions)
 	err := m.DB.Table("videos").Select("videos.id").
 		Joins("left join channels on channels.id = videos.channel_id").
-		Where("videos.title ILIKE ? OR videos.description ILIKE ? OR channels.title ILIKE ?", "%"+searchQuery+"%", "%"+searchQuery+"%", "%"+searchQuery+"%").
+		Scopes(publicVideos).
+		Where("videos.title ILIKE @search OR videos.description ILIKE @search OR channels.title ILIKE @search OR EXISTS (SELECT 1 FROM video_tags INNER JOIN tags ON tags.id = video_tags.tag_id WHERE video_tags.video_id = videos.id AND tags.name ILIKE @search) OR EXISTS (SELECT 1 FROM captions WHERE captions.video_id = videos.id AND captions.deleted_at IS NULL AND captions.text ILIKE @search)", map[string]interface{}{"search": "%" + searchQuery + "%"}).
 		Count(&count).Error
 
 	if err != nil {
@@ -139,14 +141,12 @@ func (m *postgresDBRepo) GetTotalVideosCount(searchQuery string) (int64, error)
 func (m *postgresDBRepo) GetVideoByID(id int) (*models.Video, error) {
 
 	var video models.Video
-	err := m.DB.Preload("Likes").Preload("Comments").Preload("Channel").First(&video, "id = ?", id).Error
+	err := m.DB.Preload("Likes").Preload("Comments").Preload("Channel").Preload("Tags").Preload("Chapters", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position asc")
+	}).First(&video, "id = ?", id).Error
 	if err != nil {
 		return nil, errors.New("404 video not found")
 	}
-	// update view count
-	m.DB.Model(&video).Update("views", video.Views+1)
-	video.Views = video.Views + 1
-
 	video.Channel.Subscriptions = 0
 
 	var count int64 = 0
@@ -163,10 +163,41 @@ func (m *postgresDBRepo) GetVideoByID(id int) (*models.Video, error) {
 	return &video, nil
 }
 
-// Create video
-func (m *postgresDBRepo) CreateVideo(video *models.Video) (uint, error) {
-	result := m.DB.Create(&video)
+// Count a view of the video
+func (m *postgresDBRepo) IncrementVideoViews(id uint) error {
+	result := m.DB.Model(&models.Video{}).Where("id = ?", id).UpdateColumn("views", gorm.Expr("views + 1"))
 	if result.Error != nil {
+		return errors.New("failed to update the view count")
+	}
+
+	return nil
+}
+
+// Create video
+func (m *postgresDBRepo) CreateVideo(video *models.Video, tags []string) (uint, error) {
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var err error
+	video.Tags, err = findOrCreateTags(tx, tags)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	err = tx.Create(video).Error
+	if err != nil {
+		tx.Rollback()
+		return 0, errors.New("failed to create the video")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
 		return 0, errors.New("failed to create the video")
 	}
 
@@ -185,35 +216,94 @@ func (m *postgresDBRepo) UpdateVideo(video *models.Video) error {
 
 // Delete video by ID
 func (m *postgresDBRepo) DeleteVideoModel(video *models.Video) error {
-	videoPublicID, thumbPublicID := video.PublicID, video.ThumbPublicID
+	tx := m.DB.Begin()
 
-	err := m.DB.Select(clause.Associations).Unscoped().Delete(&video).Error
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	images, err := deleteVideo(tx, video)
 	if err != nil {
+		tx.Rollback()
 		fmt.Println(err)
 		return errors.New("something went wrong. failed to delete the video")
 	}
 
-	// go routine to delete video from cloudinary
-	go func() {
-		// delete all notifications related to this video
-		_ = m.DB.Unscoped().Where("video_id = ?", video.ID).Delete(&models.Notification{}).Error
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.New("something went wrong. failed to delete the video")
+	}
 
-		// delete video
-		_ = m.DeleteVideoFromCloudinary(videoPublicID)
-		_ = m.DeleteImageFromCloudinary(thumbPublicID)
-	}()
+	go m.deleteVideoAssets(video.ID, video.PublicID, images)
 
 	return nil
 }
 
+// delete a video with everything generated for it and return the public IDs of the images to remove from cloudinary
+func deleteVideo(tx *gorm.DB, video *models.Video) ([]string, error) {
+	images := []string{video.ThumbPublicID}
+
+	var thumbnails []models.VideoThumbnail
+	err := tx.Where("video_id = ?", video.ID).Find(&thumbnails).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, thumbnail := range thumbnails {
+		images = append(images, thumbnail.SmallPublicID, thumbnail.MediumPublicID, thumbnail.LargePublicID)
+	}
+
+	// the video may have no preview yet
+	var previews []models.VideoPreview
+	err = tx.Where("video_id = ?", video.ID).Find(&previews).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, preview := range previews {
+		images = append(images, preview.PreviewPublicID, preview.SpritePublicID)
+	}
+
+	for _, model := range []interface{}{&models.VideoThumbnail{}, &models.VideoPreview{}, &models.Caption{}} {
+		err = tx.Unscoped().Where("video_id = ?", video.ID).Delete(model).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Select(clause.Associations).Unscoped().Delete(video).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
+
+// remove the files of a deleted video from cloudinary
+func (m *postgresDBRepo) deleteVideoAssets(videoID uint, videoPublicID string, images []string) {
+	// delete all notifications related to this video
+	_ = m.DB.Unscoped().Where("video_id = ?", videoID).Delete(&models.Notification{}).Error
+
+	// delete video
+	_ = m.DeleteVideoFromCloudinary(videoPublicID)
+
+	// delete the thumbnails in every size, the hover preview and the sprite sheet
+	for _, publicID := range images {
+		if publicID != "" {
+			_ = m.DeleteImageFromCloudinary(publicID)
+		}
+	}
+}
+
 // Get videos by channelID including pagination
 func (m *postgresDBRepo) GetVideosByChannelID(id, page, limit int) ([]models.VideoDTO, int64, error) {
 	var videos []models.VideoDTO
 	var count int64
 	offset := (page - 1) * limit
 
-	err := m.DB.Table("videos").Select("videos.id, videos.title, videos.thumb, videos.views, videos.created_at, channels.id as channel_id, channels.title as channel_title, channels.logo as channel_logo").
+	err := m.DB.Table("videos").Select("videos.id, videos.title, videos.thumb, videos.thumb_small, videos.thumb_large, videos.views, videos.duration, videos.width, videos.height, videos.codec, videos.file_size, videos.created_at, channels.id as channel_id, channels.title as channel_title, channels.logo as channel_logo").
 		Joins("left join channels on channels.id = videos.channel_id").
+		Scopes(publicVideos).
 		Where("videos.channel_id = ?", id).
 		Count(&count).
 		Offset(offset).Limit(limit).
@@ -360,6 +450,7 @@ func (m *postgresDBRepo) IsSubscribed(userID, channelID uint) bool {
 	return true
 }
 
+// Subscribe or unsubscribe a user to a channel, it returns the ID of the new subscription or 0 when the user unsubscribed
 func (m *postgresDBRepo) ToggleSubscription(userID, channelID uint) (uint, error) {
 	var subscription models.Subscription
 	var subscribed uint = 0
@@ -372,7 +463,7 @@ func (m *postgresDBRepo) ToggleSubscription(userID, channelID uint) (uint, error
 			fmt.Println(ts.Error, "error")
 			return 0, errors.New("failed to subscription the channel")
 		}
-		subscribed = 1
+		subscribed = subscription.ID
 	} else {
 		ts := m.DB.Unscoped().Delete(&subscription)
 		if ts.Error != nil {
@@ -421,9 +512,10 @@ func (m *postgresDBRepo) GetLikedVideos(userIDUint uint, page, limit int) ([]mod
 	var count int64
 	offset := (page - 1) * limit
 
-	err := m.DB.Table("videos").Select("videos.id, videos.title, videos.thumb, videos.views, channels.id as channel_id, channels.title as channel_title, channels.logo as channel_logo").
+	err := m.DB.Table("videos").Select("videos.id, videos.title, videos.thumb, videos.thumb_small, videos.thumb_large, videos.views, videos.duration, videos.width, videos.height, videos.codec, videos.file_size, channels.id as channel_id, channels.title as channel_title, channels.logo as channel_logo").
 		Joins("left join channels on channels.id = videos.channel_id").
 		Joins("left join likes on likes.video_id = videos.id").
+		Scopes(publicVideos).
 		Where("likes.user_id = ?", userIDUint).
 		Count(&count).
 		Offset(offset).Limit(limit).
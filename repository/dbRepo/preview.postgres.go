@@ -0,0 +1,32 @@
+package dbrepo
+
+import (
+	"errors"
+
+	"github.com/raihan2bd/vidverse/models"
+	"gorm.io/gorm/clause"
+)
+
+// Save the hover preview and sprite sheet of a video, replacing the previous ones
+func (m *postgresDBRepo) SaveVideoPreview(preview *models.VideoPreview) error {
+	result := m.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "video_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"preview_url", "preview_public_id", "sprite_url", "sprite_public_id", "sprite_interval", "thumbnail_track", "updated_at"}),
+	}).Create(preview)
+	if result.Error != nil {
+		return errors.New("failed to save the video preview")
+	}
+
+	return nil
+}
+
+// Get the hover preview and sprite sheet of a video
+func (m *postgresDBRepo) GetVideoPreview(videoID uint) (*models.VideoPreview, error) {
+	var preview models.VideoPreview
+	err := m.DB.Where("video_id = ?", videoID).First(&preview).Error
+	if err != nil {
+		return nil, errors.New("404 video preview not found")
+	}
+
+	return &preview, nil
+}
@@ -0,0 +1,76 @@
+package dbrepo
+
+import (
+	"errors"
+
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// Update the processing status of a video
+func (m *postgresDBRepo) UpdateVideoStatus(id uint, status, message string) error {
+	result := m.DB.Model(&models.Video{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":         status,
+		"status_message": message,
+	})
+	if result.Error != nil {
+		return errors.New("failed to update the video status")
+	}
+
+	return nil
+}
+
+// Update the stored files of a video once they are uploaded and replace its candidate thumbnails,
+// the replaced thumbnails are returned so their files can be removed
+func (m *postgresDBRepo) UpdateVideoAssets(video *models.Video, thumbnails []models.VideoThumbnail) ([]models.VideoThumbnail, error) {
+	var old []models.VideoThumbnail
+
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	err := tx.Where("video_id = ?", video.ID).Find(&old).Error
+	if err == nil && len(old) > 0 {
+		err = tx.Unscoped().Where("video_id = ?", video.ID).Delete(&models.VideoThumbnail{}).Error
+	}
+	if err == nil && len(thumbnails) > 0 {
+		err = tx.Create(&thumbnails).Error
+	}
+	if err == nil {
+		err = tx.Model(&models.Video{}).Where("id = ?", video.ID).Updates(map[string]interface{}{
+			"public_id":       video.PublicID,
+			"secure_url":      video.SecureURL,
+			"thumb":           video.Thumb,
+			"thumb_small":     video.ThumbSmall,
+			"thumb_large":     video.ThumbLarge,
+			"thumb_public_id": video.ThumbPublicID,
+		}).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.New("failed to update the video")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return nil, errors.New("failed to update the video")
+	}
+
+	return old, nil
+}
+
+// Mark the videos left half processed by a previous run as failed
+func (m *postgresDBRepo) FailUnfinishedVideos(message string) error {
+	result := m.DB.Model(&models.Video{}).Where("status IN ?", []string{models.StatusUploading, models.StatusProcessing}).Updates(map[string]interface{}{
+		"status":         models.StatusFailed,
+		"status_message": message,
+	})
+	if result.Error != nil {
+		return errors.New("failed to update the video status")
+	}
+
+	return nil
+}
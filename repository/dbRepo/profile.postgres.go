@@ -0,0 +1,115 @@
+package dbrepo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// Update the name and the avatar of a user
+func (m *postgresDBRepo) UpdateUserProfile(user *models.User) error {
+	err := m.DB.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"name":             user.Name,
+		"avatar":           user.Avatar,
+		"avatar_public_id": user.AvatarPublicID,
+	}).Error
+	if err != nil {
+		return errors.New("failed to update the profile")
+	}
+
+	return nil
+}
+
+// Save a new email of a user waiting to be verified, it replaces the previous one
+func (m *postgresDBRepo) CreateEmailChange(change *models.EmailChange) error {
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	err := tx.Unscoped().Where("user_id = ?", change.UserID).Delete(&models.EmailChange{}).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to change the email")
+	}
+
+	err = tx.Create(change).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to change the email")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.New("failed to change the email")
+	}
+
+	return nil
+}
+
+// Get the new email of a user waiting to be verified
+func (m *postgresDBRepo) GetPendingEmailChange(userID uint) (*models.EmailChange, error) {
+	var change models.EmailChange
+	err := m.DB.Where("user_id = ? AND expires_at > ?", userID, time.Now()).First(&change).Error
+	if err != nil {
+		return nil, errors.New("404 email change not found")
+	}
+
+	return &change, nil
+}
+
+// Get a new email waiting to be verified by its token
+func (m *postgresDBRepo) GetEmailChangeByToken(token string) (*models.EmailChange, error) {
+	var change models.EmailChange
+	err := m.DB.Where("token = ? AND expires_at > ?", token, time.Now()).First(&change).Error
+	if err != nil {
+		return nil, errors.New("the link is invalid or has expired")
+	}
+
+	return &change, nil
+}
+
+// Use the verified email as the email of the user
+func (m *postgresDBRepo) ConfirmEmailChange(change *models.EmailChange) error {
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var count int64
+	err := tx.Model(&models.User{}).Where("lower(email) = lower(?) AND id <> ?", change.NewEmail, change.UserID).Count(&count).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to change the email")
+	}
+	if count > 0 {
+		tx.Rollback()
+		return errors.New("the email address is already used by another account")
+	}
+
+	err = tx.Model(&models.User{}).Where("id = ?", change.UserID).Updates(map[string]interface{}{"email": change.NewEmail, "email_verified": true}).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to change the email")
+	}
+
+	err = tx.Unscoped().Delete(change).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to change the email")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.New("failed to change the email")
+	}
+
+	return nil
+}
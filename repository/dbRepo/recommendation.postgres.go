@@ -0,0 +1,114 @@
+package dbrepo
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// Find video by ID without touching the view count
+func (m *postgresDBRepo) FindVideoByID(id uint) (*models.Video, error) {
+	var video models.Video
+	err := m.DB.Preload("Channel").First(&video, "id = ?", id).Error
+	if err != nil {
+		return nil, errors.New("404 video not found")
+	}
+
+	return &video, nil
+}
+
+// Add the video to the watch history of the user
+func (m *postgresDBRepo) AddToWatchHistory(userID, videoID uint) error {
+	var history models.WatchHistory
+	err := m.DB.Where("user_id = ? AND video_id = ?", userID, videoID).First(&history).Error
+	if err != nil {
+		history.UserID = userID
+		history.VideoID = videoID
+		if err = m.DB.Create(&history).Error; err != nil {
+			return errors.New("failed to add the video to watch history")
+		}
+		return nil
+	}
+
+	// move the video to the top of the history
+	if err = m.DB.Model(&history).Update("updated_at", m.DB.NowFunc()).Error; err != nil {
+		return errors.New("failed to update the watch history")
+	}
+
+	return nil
+}
+
+// Get the candidates for related videos with their co-like, co-watch and shared tag counts.
+// Only videos sharing a signal, the channel or one of the keywords with the source video are considered
+func (m *postgresDBRepo) GetRelatedVideoCandidates(video *models.Video, keywords []string, limit int) ([]models.RelatedCandidate, error) {
+	var candidates []models.RelatedCandidate
+
+	textMatch := "false"
+	args := map[string]interface{}{"id": video.ID, "channel_id": video.ChannelID}
+	if len(keywords) > 0 {
+		// the keywords only hold letters and numbers so they are safe inside the pattern
+		textMatch = "(videos.title || ' ' || videos.description) ~* @pattern"
+		args["pattern"] = `\m(` + strings.Join(keywords, "|") + `)\M`
+	}
+
+	scores := m.DB.Table("videos").Select(`videos.id, videos.title, videos.description, videos.channel_id, videos.created_at,
+		(SELECT count(DISTINCT likes.user_id) FROM likes WHERE likes.video_id = videos.id AND likes.user_id IN (SELECT user_id FROM likes WHERE video_id = @id)) as co_likes,
+		(SELECT count(DISTINCT watch_histories.user_id) FROM watch_histories WHERE watch_histories.video_id = videos.id AND watch_histories.deleted_at IS NULL AND watch_histories.user_id IN (SELECT user_id FROM watch_histories WHERE video_id = @id AND deleted_at IS NULL)) as co_watches,
+		(SELECT count(*) FROM video_tags WHERE video_tags.video_id = videos.id AND video_tags.tag_id IN (SELECT tag_id FROM video_tags WHERE video_id = @id)) as shared_tags,
+		(videos.channel_id = @channel_id) as same_channel,
+		(`+textMatch+`) as text_match`,
+		args).
+		Scopes(publicVideos).
+		Where("videos.id <> ? AND videos.deleted_at IS NULL", video.ID)
+
+	err := m.DB.Table("(?) as candidates", scores).
+		Where("candidates.co_likes + candidates.co_watches + candidates.shared_tags > 0 OR candidates.same_channel OR candidates.text_match").
+		Order("candidates.co_likes + candidates.co_watches + candidates.shared_tags desc, candidates.text_match desc, candidates.created_at desc").
+		Limit(limit).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return candidates, nil
+}
+
+// Get videos by IDs keeping the order of the given IDs
+func (m *postgresDBRepo) GetVideosByIDs(ids []uint) ([]models.VideoDTO, error) {
+	var videos []models.VideoDTO
+	if len(ids) == 0 {
+		return videos, nil
+	}
+
+	err := m.DB.Table("videos").Select("videos.id, videos.title, videos.thumb, videos.thumb_small, videos.thumb_large, videos.views, videos.duration, videos.width, videos.height, videos.codec, videos.file_size, videos.created_at, channels.id as channel_id, channels.title as channel_title, channels.logo as channel_logo").
+		Joins("left join channels on channels.id = videos.channel_id").
+		Scopes(publicVideos).
+		Where("videos.id IN ?", ids).
+		Find(&videos).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	position := make(map[uint]int, len(ids))
+	for i, id := range ids {
+		position[id] = i
+	}
+
+	ordered := make([]models.VideoDTO, len(ids))
+	found := make([]bool, len(ids))
+	for _, video := range videos {
+		i := position[video.ID]
+		ordered[i] = video
+		found[i] = true
+	}
+
+	videos = videos[:0]
+	for i, video := range ordered {
+		if found[i] {
+			videos = append(videos, video)
+		}
+	}
+
+	return videos, nil
+}
@@ -0,0 +1,34 @@
+package dbrepo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// Get the processed scheduled videos whose publish time has come
+func (m *postgresDBRepo) GetDueScheduledVideos(now time.Time) ([]models.Video, error) {
+	var videos []models.Video
+	err := m.DB.Where("publish_at IS NOT NULL AND publish_at <= ? AND visibility <> ? AND status = ?", now, models.VisibilityPublic, models.StatusReady).Find(&videos).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return videos, nil
+}
+
+// Publish a scheduled video, returns false when the video was already published or rescheduled
+func (m *postgresDBRepo) PublishScheduledVideo(id uint, now time.Time) (bool, error) {
+	result := m.DB.Model(&models.Video{}).
+		Where("id = ? AND publish_at IS NOT NULL AND publish_at <= ? AND visibility <> ?", id, now, models.VisibilityPublic).
+		Updates(map[string]interface{}{
+			"visibility": models.VisibilityPublic,
+			"publish_at": nil,
+		})
+	if result.Error != nil {
+		return false, errors.New("failed to publish the video")
+	}
+
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,69 @@
+package dbrepo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// Get videos from the channels the user is subscribed to, newest first
+func (m *postgresDBRepo) GetSubscriptionFeed(userID uint, page, limit int) ([]models.VideoDTO, int64, error) {
+	var videos []models.VideoDTO
+	var count int64
+	offset := (page - 1) * limit
+
+	err := m.DB.Table("videos").Select("videos.id, videos.title, videos.thumb, videos.thumb_small, videos.thumb_large, videos.views, videos.duration, videos.width, videos.height, videos.codec, videos.file_size, videos.created_at, channels.id as channel_id, channels.title as channel_title, channels.logo as channel_logo").
+		Joins("inner join channels on channels.id = videos.channel_id").
+		Joins("inner join subscriptions on subscriptions.channel_id = videos.channel_id").
+		Scopes(publicVideos).
+		Where("subscriptions.user_id = ? AND subscriptions.deleted_at IS NULL AND channels.deleted_at IS NULL", userID).
+		Count(&count).
+		Offset(offset).Limit(limit).
+		Order("videos.created_at desc").
+		Find(&videos).Error
+	if err != nil {
+		return nil, 0, errors.New("internal server error. Please try again")
+	}
+
+	return videos, count, nil
+}
+
+// Get subscribed channels by userID with the count of videos uploaded since the user last checked the feed
+func (m *postgresDBRepo) GetSubscribedChannelsByUserID(userID uint) ([]models.SubscribedChannelDTO, error) {
+	var channels []models.SubscribedChannelDTO
+
+	err := m.DB.Table("subscriptions").Select("channels.id, channels.title, channels.logo, subscriptions.created_at as subscribed_at, count(DISTINCT videos.id) as unseen_videos").
+		Joins("inner join channels on channels.id = subscriptions.channel_id").
+		Joins("left join videos on videos.channel_id = channels.id AND videos.visibility = ? AND videos.status = ? AND videos.deleted_at IS NULL AND videos.created_at > COALESCE(subscriptions.last_seen_at, subscriptions.created_at)", models.VisibilityPublic, models.StatusReady).
+		Where("subscriptions.user_id = ? AND subscriptions.deleted_at IS NULL AND channels.deleted_at IS NULL", userID).
+		Group("channels.id, subscriptions.id").
+		Order("unseen_videos desc, channels.title asc").
+		Find(&channels).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return channels, nil
+}
+
+// Mark all the subscriptions of a user as seen
+func (m *postgresDBRepo) MarkSubscriptionsAsSeen(userID uint) error {
+	result := m.DB.Model(&models.Subscription{}).Where("user_id = ?", userID).Update("last_seen_at", time.Now())
+	if result.Error != nil {
+		return errors.New("failed to update the subscriptions")
+	}
+
+	return nil
+}
+
+// Get the IDs of the users subscribed to a channel
+func (m *postgresDBRepo) GetSubscriberIDsByChannelID(channelID uint) ([]uint, error) {
+	var userIDs []uint
+	err := m.DB.Table("subscriptions").Select("subscriptions.user_id").Where("subscriptions.channel_id = ? AND subscriptions.deleted_at IS NULL", channelID).Find(&userIDs).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return userIDs, nil
+}
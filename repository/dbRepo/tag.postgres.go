@@ -0,0 +1,119 @@
+package dbrepo
+
+import (
+	"errors"
+
+	"github.com/raihan2bd/vidverse/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Find the tags by name and create the missing ones
+func (m *postgresDBRepo) FindOrCreateTags(names []string) ([]models.Tag, error) {
+	return findOrCreateTags(m.DB, names)
+}
+
+func findOrCreateTags(db *gorm.DB, names []string) ([]models.Tag, error) {
+	var tags []models.Tag
+	if len(names) == 0 {
+		return tags, nil
+	}
+
+	newTags := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		newTags = append(newTags, models.Tag{Name: name})
+	}
+
+	err := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).Create(&newTags).Error
+	if err != nil {
+		return nil, errors.New("failed to save the tags")
+	}
+
+	err = db.Where("name IN ?", names).Find(&tags).Error
+	if err != nil {
+		return nil, errors.New("failed to save the tags")
+	}
+
+	return tags, nil
+}
+
+// Update the video and replace its tags with the given names
+func (m *postgresDBRepo) UpdateVideoWithTags(video *models.Video, tags []string) error {
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	videoTags, err := findOrCreateTags(tx, tags)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Omit("Tags").Save(video).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to update the video")
+	}
+
+	err = tx.Model(video).Association("Tags").Replace(videoTags)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to update the video tags")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.New("failed to update the video")
+	}
+
+	video.Tags = videoTags
+	return nil
+}
+
+// Get videos by tag with pagination
+func (m *postgresDBRepo) GetVideosByTag(tag string, page, limit int) ([]models.VideoDTO, int64, error) {
+	var videos []models.VideoDTO
+	var count int64
+	offset := (page - 1) * limit
+
+	err := m.DB.Table("videos").Select("videos.id, videos.title, videos.thumb, videos.thumb_small, videos.thumb_large, videos.views, videos.duration, videos.width, videos.height, videos.codec, videos.file_size, videos.created_at, channels.id as channel_id, channels.title as channel_title, channels.logo as channel_logo").
+		Joins("left join channels on channels.id = videos.channel_id").
+		Joins("inner join video_tags on video_tags.video_id = videos.id").
+		Joins("inner join tags on tags.id = video_tags.tag_id").
+		Scopes(publicVideos).
+		Where("tags.name = ? AND videos.deleted_at IS NULL", tag).
+		Count(&count).
+		Offset(offset).Limit(limit).
+		Order("videos.created_at desc").
+		Find(&videos).Error
+	if err != nil {
+		return nil, 0, errors.New("internal server error. Please try again")
+	}
+
+	return videos, count, nil
+}
+
+// Get videos by category with pagination
+func (m *postgresDBRepo) GetVideosByCategory(category string, page, limit int) ([]models.VideoDTO, int64, error) {
+	var videos []models.VideoDTO
+	var count int64
+	offset := (page - 1) * limit
+
+	err := m.DB.Table("videos").Select("videos.id, videos.title, videos.thumb, videos.thumb_small, videos.thumb_large, videos.views, videos.duration, videos.width, videos.height, videos.codec, videos.file_size, videos.created_at, channels.id as channel_id, channels.title as channel_title, channels.logo as channel_logo").
+		Joins("left join channels on channels.id = videos.channel_id").
+		Scopes(publicVideos).
+		Where("videos.category = ? AND videos.deleted_at IS NULL", category).
+		Count(&count).
+		Offset(offset).Limit(limit).
+		Order("videos.created_at desc").
+		Find(&videos).Error
+	if err != nil {
+		return nil, 0, errors.New("internal server error. Please try again")
+	}
+
+	return videos, count, nil
+}
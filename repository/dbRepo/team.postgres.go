@@ -0,0 +1,241 @@
+package dbrepo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/raihan2bd/vidverse/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Get the role of a user in a channel, an empty role means the user is not a member
+func (m *postgresDBRepo) GetChannelRole(channelID, userID uint) (string, error) {
+	var channel models.Channel
+	err := m.DB.Select("id, user_id").First(&channel, channelID).Error
+	if err != nil {
+		return "", errors.New("404 channel not found")
+	}
+
+	if channel.UserID == userID {
+		return models.RoleOwner, nil
+	}
+
+	var member models.ChannelMember
+	err = m.DB.Where("channel_id = ? AND user_id = ?", channelID, userID).First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.New("internal server error. Please try again")
+	}
+
+	return member.Role, nil
+}
+
+// Get the IDs of the channels a user owns or is a member of with one of the roles
+func memberChannelIDs(db *gorm.DB, userID uint, roles []string) ([]uint, error) {
+	var ids []uint
+	err := db.Raw(`SELECT id FROM channels WHERE user_id = ? AND deleted_at IS NULL
+		UNION SELECT channel_id FROM channel_members WHERE user_id = ? AND role IN ? AND deleted_at IS NULL`, userID, userID, roles).
+		Scan(&ids).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return ids, nil
+}
+
+// Get the owner and the members of a channel
+func (m *postgresDBRepo) GetChannelMembers(channelID uint) ([]models.ChannelMemberDTO, error) {
+	var members []models.ChannelMemberDTO
+
+	err := m.DB.Table("channels").Select("users.id as user_id, users.name, users.email, users.avatar, ? as role, channels.created_at as joined_at", models.RoleOwner).
+		Joins("join users on users.id = channels.user_id").
+		Where("channels.id = ?", channelID).
+		Scan(&members).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	var others []models.ChannelMemberDTO
+	err = m.DB.Table("channel_members").Select("users.id as user_id, users.name, users.email, users.avatar, channel_members.role, channel_members.created_at as joined_at").
+		Joins("join users on users.id = channel_members.user_id").
+		Where("channel_members.channel_id = ? AND channel_members.deleted_at IS NULL", channelID).
+		Order("channel_members.created_at asc").
+		Scan(&others).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return append(members, others...), nil
+}
+
+// Change the role of a member of a channel
+func (m *postgresDBRepo) UpdateChannelMemberRole(channelID, userID uint, role string) error {
+	result := m.DB.Model(&models.ChannelMember{}).Where("channel_id = ? AND user_id = ?", channelID, userID).Update("role", role)
+	if result.Error != nil {
+		return errors.New("failed to update the member")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("404 member not found")
+	}
+
+	return nil
+}
+
+// Remove a member from a channel
+func (m *postgresDBRepo) DeleteChannelMember(channelID, userID uint) error {
+	result := m.DB.Unscoped().Where("channel_id = ? AND user_id = ?", channelID, userID).Delete(&models.ChannelMember{})
+	if result.Error != nil {
+		return errors.New("failed to remove the member")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("404 member not found")
+	}
+
+	return nil
+}
+
+// Create an invite to a channel, a pending invite to the same email is replaced
+func (m *postgresDBRepo) CreateChannelInvite(invite *models.ChannelInvite) error {
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	err := tx.Unscoped().Where("channel_id = ? AND lower(email) = lower(?) AND accepted_at IS NULL", invite.ChannelID, invite.Email).Delete(&models.ChannelInvite{}).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to create the invite")
+	}
+
+	err = tx.Create(invite).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to create the invite")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.New("failed to create the invite")
+	}
+
+	return nil
+}
+
+// Get the invites of a channel that are not accepted or expired yet
+func (m *postgresDBRepo) GetChannelInvites(channelID uint) ([]models.ChannelInvite, error) {
+	var invites []models.ChannelInvite
+	err := m.DB.Where("channel_id = ? AND accepted_at IS NULL AND expires_at > ?", channelID, time.Now()).
+		Order("created_at desc").
+		Find(&invites).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return invites, nil
+}
+
+// Get a pending invite by its token
+func (m *postgresDBRepo) GetChannelInviteByToken(token string) (*models.ChannelInvite, error) {
+	var invite models.ChannelInvite
+	err := m.DB.Where("token = ? AND accepted_at IS NULL AND expires_at > ?", token, time.Now()).First(&invite).Error
+	if err != nil {
+		return nil, errors.New("the invite is not found or has expired")
+	}
+
+	return &invite, nil
+}
+
+// Cancel an invite of a channel
+func (m *postgresDBRepo) DeleteChannelInvite(channelID, inviteID uint) error {
+	result := m.DB.Unscoped().Where("id = ? AND channel_id = ? AND accepted_at IS NULL", inviteID, channelID).Delete(&models.ChannelInvite{})
+	if result.Error != nil {
+		return errors.New("failed to cancel the invite")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("404 invite not found")
+	}
+
+	return nil
+}
+
+// Accept an invite, the user becomes a member of the channel with the role of the invite
+func (m *postgresDBRepo) AcceptChannelInvite(invite *models.ChannelInvite, userID uint) error {
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// an invite is only used once
+	result := tx.Model(&models.ChannelInvite{}).Where("id = ? AND accepted_at IS NULL", invite.ID).Update("accepted_at", time.Now())
+	if result.Error != nil || result.RowsAffected == 0 {
+		tx.Rollback()
+		return errors.New("the invite is not found or has expired")
+	}
+
+	member := models.ChannelMember{ChannelID: invite.ChannelID, UserID: userID, Role: invite.Role}
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "channel_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+	}).Create(&member).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to accept the invite")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.New("failed to accept the invite")
+	}
+
+	return nil
+}
+
+// Give a channel to one of its members, the old owner stays as a manager
+func (m *postgresDBRepo) TransferChannelOwnership(channelID, fromUserID, toUserID uint) error {
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	result := tx.Unscoped().Where("channel_id = ? AND user_id = ?", channelID, toUserID).Delete(&models.ChannelMember{})
+	if result.Error != nil {
+		tx.Rollback()
+		return errors.New("failed to transfer the channel")
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return errors.New("the new owner must be a member of the channel")
+	}
+
+	result = tx.Model(&models.Channel{}).Where("id = ? AND user_id = ?", channelID, fromUserID).Update("user_id", toUserID)
+	if result.Error != nil || result.RowsAffected == 0 {
+		tx.Rollback()
+		return errors.New("failed to transfer the channel")
+	}
+
+	member := models.ChannelMember{ChannelID: channelID, UserID: fromUserID, Role: models.RoleManager}
+	err := tx.Create(&member).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to transfer the channel")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.New("failed to transfer the channel")
+	}
+
+	return nil
+}
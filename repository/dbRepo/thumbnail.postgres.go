@@ -0,0 +1,73 @@
+package dbrepo
+
+import (
+	"errors"
+
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// Save the candidate thumbnails of a video
+func (m *postgresDBRepo) CreateVideoThumbnails(thumbnails []models.VideoThumbnail) error {
+	if len(thumbnails) == 0 {
+		return nil
+	}
+
+	result := m.DB.Create(&thumbnails)
+	if result.Error != nil {
+		return errors.New("failed to save the thumbnails")
+	}
+
+	return nil
+}
+
+// Get the candidate thumbnails of a video
+func (m *postgresDBRepo) GetVideoThumbnails(videoID uint) ([]models.VideoThumbnail, error) {
+	var thumbnails []models.VideoThumbnail
+	err := m.DB.Where("video_id = ?", videoID).Order("is_custom desc, timestamp asc").Find(&thumbnails).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return thumbnails, nil
+}
+
+// Use one of the candidate thumbnails as the thumbnail of the video
+func (m *postgresDBRepo) SelectVideoThumbnail(videoID, thumbnailID uint) (*models.VideoThumbnail, error) {
+	var thumbnail models.VideoThumbnail
+	err := m.DB.Where("id = ? AND video_id = ?", thumbnailID, videoID).First(&thumbnail).Error
+	if err != nil {
+		return nil, errors.New("404 thumbnail not found")
+	}
+
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	err = tx.Model(&models.VideoThumbnail{}).Where("video_id = ?", videoID).Update("is_selected", false).Error
+	if err == nil {
+		err = tx.Model(&thumbnail).Update("is_selected", true).Error
+	}
+	if err == nil {
+		err = tx.Model(&models.Video{}).Where("id = ?", videoID).Updates(map[string]interface{}{
+			"thumb":       thumbnail.Medium,
+			"thumb_small": thumbnail.Small,
+			"thumb_large": thumbnail.Large,
+		}).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.New("failed to update the thumbnail")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return nil, errors.New("failed to update the thumbnail")
+	}
+
+	thumbnail.IsSelected = true
+	return &thumbnail, nil
+}
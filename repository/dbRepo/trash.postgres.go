@@ -0,0 +1,177 @@
+package dbrepo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// Move a video to the trash
+func (m *postgresDBRepo) TrashVideo(video *models.Video) error {
+	err := m.DB.Delete(&models.Video{}, video.ID).Error
+	if err != nil {
+		return errors.New("something went wrong. failed to delete the video")
+	}
+
+	return nil
+}
+
+// Move a channel with its videos to the trash, the videos keep the time of the channel so they are restored together
+func (m *postgresDBRepo) TrashChannel(channelID uint) error {
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	now := time.Now()
+
+	err := tx.Model(&models.Video{}).Where("channel_id = ?", channelID).Update("deleted_at", now).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to delete the channel")
+	}
+
+	result := tx.Model(&models.Channel{}).Where("id = ?", channelID).Update("deleted_at", now)
+	if result.Error != nil || result.RowsAffected == 0 {
+		tx.Rollback()
+		return errors.New("failed to delete the channel")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.New("failed to delete the channel")
+	}
+
+	return nil
+}
+
+// Find a video in the trash
+func (m *postgresDBRepo) FindTrashedVideo(id uint) (*models.Video, error) {
+	var video models.Video
+	err := m.DB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&video).Error
+	if err != nil {
+		return nil, errors.New("404 video not found in the trash")
+	}
+
+	return &video, nil
+}
+
+// Find a channel in the trash
+func (m *postgresDBRepo) FindTrashedChannel(id uint) (*models.Channel, error) {
+	var channel models.Channel
+	err := m.DB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&channel).Error
+	if err != nil {
+		return nil, errors.New("404 channel not found in the trash")
+	}
+
+	return &channel, nil
+}
+
+// Take a video out of the trash
+func (m *postgresDBRepo) RestoreVideo(id uint) error {
+	result := m.DB.Unscoped().Model(&models.Video{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	if result.Error != nil {
+		return errors.New("failed to restore the video")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("404 video not found in the trash")
+	}
+
+	return nil
+}
+
+// Take a channel out of the trash with the videos that were deleted with it
+func (m *postgresDBRepo) RestoreChannel(id uint) error {
+	channel, err := m.FindTrashedChannel(id)
+	if err != nil {
+		return err
+	}
+
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// videos deleted on their own before the channel stay in the trash
+	err = tx.Unscoped().Model(&models.Video{}).Where("channel_id = ? AND deleted_at = ?", id, channel.DeletedAt.Time).Update("deleted_at", nil).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to restore the channel")
+	}
+
+	err = tx.Unscoped().Model(&models.Channel{}).Where("id = ?", id).Update("deleted_at", nil).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to restore the channel")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.New("failed to restore the channel")
+	}
+
+	return nil
+}
+
+// Get the channels of a user in the trash
+func (m *postgresDBRepo) GetTrashedChannels(userID uint) ([]models.TrashedChannelDTO, error) {
+	var channels []models.TrashedChannelDTO
+	err := m.DB.Table("channels").Select("id, title, handle, logo, deleted_at").
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at desc").
+		Scan(&channels).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return channels, nil
+}
+
+// Get the videos in the trash of the channels a user manages the videos of, videos of a trashed channel come back with the channel
+func (m *postgresDBRepo) GetTrashedVideos(userID uint) ([]models.TrashedVideoDTO, error) {
+	channelIDs, err := memberChannelIDs(m.DB, userID, models.RolesWith(models.PermManageVideos))
+	if err != nil {
+		return nil, err
+	}
+
+	var videos []models.TrashedVideoDTO
+	err = m.DB.Table("videos").Select("videos.id, videos.title, videos.thumb, videos.channel_id, channels.title as channel_title, videos.deleted_at").
+		Joins("inner join channels on channels.id = videos.channel_id AND channels.deleted_at IS NULL").
+		Where("videos.channel_id IN ? AND videos.deleted_at IS NOT NULL", channelIDs).
+		Order("videos.deleted_at desc").
+		Scan(&videos).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return videos, nil
+}
+
+// Get the IDs of the channels that were put in the trash before the time
+func (m *postgresDBRepo) GetExpiredTrashedChannelIDs(before time.Time) ([]uint, error) {
+	var ids []uint
+	err := m.DB.Unscoped().Model(&models.Channel{}).Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Pluck("id", &ids).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return ids, nil
+}
+
+// Get the videos that were put in the trash before the time
+func (m *postgresDBRepo) GetExpiredTrashedVideos(before time.Time) ([]models.Video, error) {
+	var videos []models.Video
+	err := m.DB.Unscoped().Select("id, public_id, thumb_public_id").Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Find(&videos).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return videos, nil
+}
@@ -0,0 +1,107 @@
+package dbrepo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// Get the activity of every video since the given time to compute the trending score
+func (m *postgresDBRepo) GetTrendingStats(since time.Time) ([]models.TrendingStat, error) {
+	var stats []models.TrendingStat
+
+	recentViews := m.DB.Table("analytics_events").Select("video_id, count(*) as views").
+		Where("type = ? AND created_at > ?", models.EventView, since).
+		Group("video_id")
+
+	err := m.DB.Table("videos").Select(`videos.id as video_id, videos.views, videos.created_at,
+		COALESCE(recent_views.views, 0) as recent_views,
+		(SELECT count(*) FROM likes WHERE likes.video_id = videos.id AND likes.created_at > @since) as recent_likes,
+		(SELECT count(*) FROM comments WHERE comments.video_id = videos.id AND comments.created_at > @since) as recent_comments`,
+		map[string]interface{}{"since": since}).
+		Joins("left join (?) as recent_views on recent_views.video_id = videos.id", recentViews).
+		Scopes(publicVideos).
+		Where("videos.deleted_at IS NULL").
+		Find(&stats).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	return stats, nil
+}
+
+// Replace the materialized trending table with the new scores
+func (m *postgresDBRepo) ReplaceTrendingVideos(videos []models.TrendingVideo) error {
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	err := tx.Exec("DELETE FROM trending_videos").Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to refresh the trending videos")
+	}
+
+	if len(videos) > 0 {
+		err = tx.CreateInBatches(videos, 500).Error
+		if err != nil {
+			tx.Rollback()
+			return errors.New("failed to refresh the trending videos")
+		}
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.New("failed to refresh the trending videos")
+	}
+
+	return nil
+}
+
+// Get trending videos with pagination
+func (m *postgresDBRepo) GetTrendingVideos(page, limit int) ([]models.VideoDTO, int64, error) {
+	var videos []models.VideoDTO
+	var count int64
+	offset := (page - 1) * limit
+
+	err := m.DB.Table("trending_videos").Select("videos.id, videos.title, videos.thumb, videos.thumb_small, videos.thumb_large, videos.views, videos.duration, videos.width, videos.height, videos.codec, videos.file_size, videos.created_at, channels.id as channel_id, channels.title as channel_title, channels.logo as channel_logo").
+		Joins("inner join videos on videos.id = trending_videos.video_id").
+		Joins("left join channels on channels.id = videos.channel_id").
+		Scopes(publicVideos).
+		Where("trending_videos.score > 0 AND videos.deleted_at IS NULL").
+		Count(&count).
+		Offset(offset).Limit(limit).
+		Order("trending_videos.score desc, videos.created_at desc").
+		Find(&videos).Error
+	if err != nil {
+		return nil, 0, errors.New("internal server error. Please try again")
+	}
+
+	return videos, count, nil
+}
+
+// Get the newest videos with pagination
+func (m *postgresDBRepo) GetLatestVideos(page, limit int) ([]models.VideoDTO, int64, error) {
+	var videos []models.VideoDTO
+	var count int64
+	offset := (page - 1) * limit
+
+	err := m.DB.Table("videos").Select("videos.id, videos.title, videos.thumb, videos.thumb_small, videos.thumb_large, videos.views, videos.duration, videos.width, videos.height, videos.codec, videos.file_size, videos.created_at, channels.id as channel_id, channels.title as channel_title, channels.logo as channel_logo").
+		Joins("left join channels on channels.id = videos.channel_id").
+		Scopes(publicVideos).
+		Where("videos.deleted_at IS NULL").
+		Count(&count).
+		Offset(offset).Limit(limit).
+		Order("videos.created_at desc").
+		Find(&videos).Error
+	if err != nil {
+		return nil, 0, errors.New("internal server error. Please try again")
+	}
+
+	return videos, count, nil
+}
@@ -0,0 +1,162 @@
+package dbrepo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/raihan2bd/vidverse/models"
+	"gorm.io/gorm"
+)
+
+// Save the secret of a two-factor enrolment waiting for its first code
+func (m *postgresDBRepo) SetTOTPSecret(userID uint, secret string) error {
+	err := m.DB.Model(&models.User{}).Where("id = ? AND totp_enabled = ?", userID, false).Update("totp_secret", secret).Error
+	if err != nil {
+		return errors.New("failed to set up two-factor authentication")
+	}
+
+	return nil
+}
+
+// save the recovery codes of a user in place of the old ones
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, codeHashes []string) error {
+	err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+	if err != nil {
+		return err
+	}
+
+	codes := make([]models.RecoveryCode, len(codeHashes))
+	for i, hash := range codeHashes {
+		codes[i] = models.RecoveryCode{UserID: userID, CodeHash: hash}
+	}
+
+	return tx.Create(&codes).Error
+}
+
+// Turn on two-factor authentication once the first code is checked, with new recovery codes
+func (m *postgresDBRepo) EnableTOTP(userID uint, step int64, codeHashes []string) error {
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"totp_enabled":   true,
+		"totp_last_step": step,
+	}).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to enable two-factor authentication")
+	}
+
+	err = replaceRecoveryCodes(tx, userID, codeHashes)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to enable two-factor authentication")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.New("failed to enable two-factor authentication")
+	}
+
+	return nil
+}
+
+// Turn off two-factor authentication and remove the secret and the recovery codes
+func (m *postgresDBRepo) DisableTOTP(userID uint) error {
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"totp_enabled":   false,
+		"totp_secret":    "",
+		"totp_last_step": 0,
+	}).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to disable two-factor authentication")
+	}
+
+	err = tx.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to disable two-factor authentication")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.New("failed to disable two-factor authentication")
+	}
+
+	return nil
+}
+
+// Replace the recovery codes of a user
+func (m *postgresDBRepo) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	err := replaceRecoveryCodes(tx, userID, codeHashes)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to create the recovery codes")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.New("failed to create the recovery codes")
+	}
+
+	return nil
+}
+
+// Count the recovery codes of a user that are not used yet
+func (m *postgresDBRepo) CountRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	err := m.DB.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	if err != nil {
+		return 0, errors.New("internal server error. Please try again")
+	}
+
+	return count, nil
+}
+
+// Mark the time step of a code as used, a code can not be used twice
+func (m *postgresDBRepo) UseTOTPStep(userID uint, step int64) error {
+	result := m.DB.Model(&models.User{}).Where("id = ? AND totp_last_step < ?", userID, step).Update("totp_last_step", step)
+	if result.Error != nil {
+		return errors.New("internal server error. Please try again")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("this code is already used. Please wait for the next one")
+	}
+
+	return nil
+}
+
+// Use a recovery code of a user, each code works once
+func (m *postgresDBRepo) UseRecoveryCode(userID uint, codeHash string) error {
+	result := m.DB.Model(&models.RecoveryCode{}).Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).Update("used_at", time.Now())
+	if result.Error != nil {
+		return errors.New("internal server error. Please try again")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("invalid recovery code")
+	}
+
+	return nil
+}
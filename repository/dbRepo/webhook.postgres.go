@@ -0,0 +1,230 @@
+package dbrepo
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/raihan2bd/vidverse/models"
+	"gorm.io/gorm/clause"
+)
+
+// Create a webhook of a channel
+func (m *postgresDBRepo) CreateWebhook(webhook *models.Webhook) error {
+	err := m.DB.Create(webhook).Error
+	if err != nil {
+		return errors.New("failed to create the webhook")
+	}
+
+	webhook.EventList = strings.Split(webhook.Events, ",")
+	return nil
+}
+
+// Get the webhooks of a channel
+func (m *postgresDBRepo) GetWebhooks(channelID uint) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := m.DB.Where("channel_id = ?", channelID).Order("created_at asc").Find(&webhooks).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	for i := range webhooks {
+		webhooks[i].EventList = strings.Split(webhooks[i].Events, ",")
+	}
+
+	return webhooks, nil
+}
+
+// Get a webhook of a channel
+func (m *postgresDBRepo) GetWebhook(channelID, id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := m.DB.Where("id = ? AND channel_id = ?", id, channelID).First(&webhook).Error
+	if err != nil {
+		return nil, errors.New("404 webhook not found")
+	}
+
+	webhook.EventList = strings.Split(webhook.Events, ",")
+	return &webhook, nil
+}
+
+// Save the URL, the events and the state of a webhook
+func (m *postgresDBRepo) UpdateWebhook(webhook *models.Webhook) error {
+	err := m.DB.Model(webhook).Select("url", "events", "active").Updates(webhook).Error
+	if err != nil {
+		return errors.New("failed to update the webhook")
+	}
+
+	webhook.EventList = strings.Split(webhook.Events, ",")
+	return nil
+}
+
+// Delete a webhook of a channel with its delivery logs
+func (m *postgresDBRepo) DeleteWebhook(channelID, id uint) error {
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	result := tx.Unscoped().Where("id = ? AND channel_id = ?", id, channelID).Delete(&models.Webhook{})
+	if result.Error != nil {
+		tx.Rollback()
+		return errors.New("failed to delete the webhook")
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return errors.New("404 webhook not found")
+	}
+
+	err := tx.Unscoped().Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New("failed to delete the webhook")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.New("failed to delete the webhook")
+	}
+
+	return nil
+}
+
+// Get the active webhooks of a channel registered for an event
+func (m *postgresDBRepo) GetWebhooksForEvent(channelID uint, event string) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := m.DB.Where("channel_id = ? AND active = ?", channelID, true).Find(&webhooks).Error
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again")
+	}
+
+	var registered []models.Webhook
+	for _, webhook := range webhooks {
+		if webhook.HasEvent(event) {
+			registered = append(registered, webhook)
+		}
+	}
+
+	return registered, nil
+}
+
+// Queue the deliveries of an event
+func (m *postgresDBRepo) CreateWebhookDeliveries(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	err := m.DB.Create(&deliveries).Error
+	if err != nil {
+		return errors.New("failed to queue the webhook deliveries")
+	}
+
+	return nil
+}
+
+// Create a delivery of a webhook
+func (m *postgresDBRepo) CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	err := m.DB.Create(delivery).Error
+	if err != nil {
+		return errors.New("failed to create the webhook delivery")
+	}
+
+	return nil
+}
+
+// Claim the delivery that is due the longest for a worker, it stays claimed for the lease so other workers and instances skip it
+// and a delivery whose worker died is sent again once the lease is over. nil is returned when nothing is due
+func (m *postgresDBRepo) ClaimWebhookDelivery(now time.Time, lease time.Duration) (*models.WebhookDelivery, error) {
+	tx := m.DB.Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var deliveries []models.WebhookDelivery
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status IN ? AND next_attempt_at <= ?", []string{models.DeliveryPending, models.DeliverySending}, now).
+		Order("next_attempt_at asc").
+		Limit(1).
+		Find(&deliveries).Error
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.New("internal server error. Please try again")
+	}
+	if len(deliveries) == 0 {
+		tx.Rollback()
+		return nil, nil
+	}
+
+	delivery := deliveries[0]
+	until := now.Add(lease)
+	err = tx.Model(&delivery).Updates(map[string]interface{}{"status": models.DeliverySending, "next_attempt_at": until}).Error
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.New("failed to claim the webhook delivery")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return nil, errors.New("failed to claim the webhook delivery")
+	}
+
+	delivery.Status = models.DeliverySending
+	delivery.NextAttemptAt = &until
+	return &delivery, nil
+}
+
+// Get a webhook by its ID, whatever its channel
+func (m *postgresDBRepo) GetWebhookByID(id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := m.DB.First(&webhook, id).Error
+	if err != nil {
+		return nil, errors.New("404 webhook not found")
+	}
+
+	return &webhook, nil
+}
+
+// Save the result of an attempt of a delivery
+func (m *postgresDBRepo) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	err := m.DB.Model(delivery).
+		Select("status", "attempts", "next_attempt_at", "response_status", "error", "delivered_at").
+		Updates(delivery).Error
+	if err != nil {
+		return errors.New("failed to update the webhook delivery")
+	}
+
+	return nil
+}
+
+// Get the delivery logs of a webhook, the newest first
+func (m *postgresDBRepo) GetWebhookDeliveries(webhookID uint, page, limit int) ([]models.WebhookDelivery, int64, error) {
+	var deliveries []models.WebhookDelivery
+	var count int64
+	offset := (page - 1) * limit
+
+	err := m.DB.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID).
+		Count(&count).
+		Order("created_at desc").
+		Offset(offset).Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, 0, errors.New("internal server error. Please try again")
+	}
+
+	return deliveries, count, nil
+}
+
+// Delete the delivery logs older than a time
+func (m *postgresDBRepo) DeleteOldWebhookDeliveries(before time.Time) error {
+	err := m.DB.Unscoped().Where("created_at < ? AND status NOT IN ?", before, []string{models.DeliveryPending, models.DeliverySending}).Delete(&models.WebhookDelivery{}).Error
+	if err != nil {
+		return errors.New("failed to delete the old webhook deliveries")
+	}
+
+	return nil
+}
@@ -1,6 +1,10 @@
 package repository
 
-import "github.com/raihan2bd/vidverse/models"
+import (
+	"time"
+
+	"github.com/raihan2bd/vidverse/models"
+)
 
 type DatabaseRepo interface {
 	CreateNewUser(user *models.User) (int, error)
@@ -9,16 +13,109 @@ type DatabaseRepo interface {
 	GetUserByID(id uint) (*models.User, error)
 	AddForgotPasswordToken(token *models.Token) error
 	UpdateUserPassword(user *models.User) error
+	UpdateUserProfile(user *models.User) error
+	CreateEmailChange(change *models.EmailChange) error
+	GetPendingEmailChange(userID uint) (*models.EmailChange, error)
+	GetEmailChangeByToken(token string) (*models.EmailChange, error)
+	ConfirmEmailChange(change *models.EmailChange) error
+	CreateDataExport(export *models.DataExport) error
+	GetDataExports(userID uint) ([]models.DataExport, error)
+	GetDataExport(userID, id uint) (*models.DataExport, error)
+	GetPendingDataExports() ([]models.DataExport, error)
+	ResetUnfinishedDataExports() error
+	UpdateDataExport(export *models.DataExport) error
+	GetExpiredDataExports(now time.Time) ([]models.DataExport, error)
+	DeleteDataExport(id uint) error
+	GetUserData(userID uint) (*models.UserData, error)
+	ScheduleAccountDeletion(userID uint, deleteAfter *time.Time) error
+	GetAccountsDueForDeletion(now time.Time) ([]models.User, error)
+	GetOwnedChannelIDs(userID uint) ([]uint, error)
+	DeleteUserAccount(userID uint) error
+	SetTOTPSecret(userID uint, secret string) error
+	EnableTOTP(userID uint, step int64, codeHashes []string) error
+	DisableTOTP(userID uint) error
+	ReplaceRecoveryCodes(userID uint, codeHashes []string) error
+	CountRecoveryCodes(userID uint) (int64, error)
+	UseTOTPStep(userID uint, step int64) error
+	UseRecoveryCode(userID uint, codeHash string) error
+	CreateOIDCState(state *models.OIDCState) error
+	TakeOIDCState(state, provider string) (*models.OIDCState, error)
+	GetUserIdentity(provider, subject string) (*models.UserIdentity, error)
+	CreateUserIdentity(identity *models.UserIdentity) error
+	CreateOIDCUser(user *models.User, identity *models.UserIdentity) error
+	CreateAPIKey(key *models.APIKey) error
+	GetAPIKeys(userID uint) ([]models.APIKey, error)
+	GetAPIKeyByHash(hash string) (*models.APIKey, error)
+	TouchAPIKey(id uint) error
+	DeleteAPIKey(userID, id uint) error
+	CreateWebhook(webhook *models.Webhook) error
+	GetWebhooks(channelID uint) ([]models.Webhook, error)
+	GetWebhook(channelID, id uint) (*models.Webhook, error)
+	GetWebhookByID(id uint) (*models.Webhook, error)
+	UpdateWebhook(webhook *models.Webhook) error
+	DeleteWebhook(channelID, id uint) error
+	GetWebhooksForEvent(channelID uint, event string) ([]models.Webhook, error)
+	CreateWebhookDeliveries(deliveries []models.WebhookDelivery) error
+	CreateWebhookDelivery(delivery *models.WebhookDelivery) error
+	ClaimWebhookDelivery(now time.Time, lease time.Duration) (*models.WebhookDelivery, error)
+	UpdateWebhookDelivery(delivery *models.WebhookDelivery) error
+	GetWebhookDeliveries(webhookID uint, page, limit int) ([]models.WebhookDelivery, int64, error)
+	DeleteOldWebhookDeliveries(before time.Time) error
 
 	GetAllVideos(page, limit int, searchQuery string) ([]models.VideoDTO, int64, error)
 	GetTotalVideosCount(searchQuery string) (int64, error)
 	GetVideoByID(id int) (*models.Video, error)
+	IncrementVideoViews(id uint) error
+	FindVideoByID(id uint) (*models.Video, error)
+	GetVideosByIDs(ids []uint) ([]models.VideoDTO, error)
+	GetRelatedVideoCandidates(video *models.Video, keywords []string, limit int) ([]models.RelatedCandidate, error)
+	AddToWatchHistory(userID, videoID uint) error
+	GetLatestVideos(page, limit int) ([]models.VideoDTO, int64, error)
+	GetTrendingVideos(page, limit int) ([]models.VideoDTO, int64, error)
+	GetTrendingStats(since time.Time) ([]models.TrendingStat, error)
+	ReplaceTrendingVideos(videos []models.TrendingVideo) error
+
+	FindOrCreateTags(names []string) ([]models.Tag, error)
+	UpdateVideoWithTags(video *models.Video, tags []string) error
+	GetVideosByTag(tag string, page, limit int) ([]models.VideoDTO, int64, error)
+	GetVideosByCategory(category string, page, limit int) ([]models.VideoDTO, int64, error)
+
+	GetDueScheduledVideos(now time.Time) ([]models.Video, error)
+	PublishScheduledVideo(id uint, now time.Time) (bool, error)
+
+	UpdateVideoStatus(id uint, status, message string) error
+	UpdateVideoAssets(video *models.Video, thumbnails []models.VideoThumbnail) ([]models.VideoThumbnail, error)
+	FailUnfinishedVideos(message string) error
+
+	CreateVideoThumbnails(thumbnails []models.VideoThumbnail) error
+	GetVideoThumbnails(videoID uint) ([]models.VideoThumbnail, error)
+	SelectVideoThumbnail(videoID, thumbnailID uint) (*models.VideoThumbnail, error)
+	SaveVideoPreview(preview *models.VideoPreview) error
+	GetVideoPreview(videoID uint) (*models.VideoPreview, error)
+	SaveCaption(caption *models.Caption) (*models.Caption, error)
+	GetCaptionsByVideoID(videoID uint) ([]models.Caption, error)
+	GetCaption(videoID uint, language string) (*models.Caption, error)
+	DeleteCaption(videoID uint, language string) error
+	ReplaceVideoChapters(videoID uint, chapters []models.Chapter) error
+	BulkUpdateVideos(userID uint, isAdmin bool, videoIDs []uint, action *models.BulkVideoAction) ([]models.BulkVideoResult, error)
+	RecordAnalyticsEvent(event *models.AnalyticsEvent) error
+	RollupAnalytics() error
+	GetChannelDailyStats(channelID, videoID uint, from, to time.Time) ([]models.DailyStatDTO, error)
+	GetChannelTopVideos(channelID uint, from, to time.Time, limit int) ([]models.VideoStatDTO, error)
+	StreamChannelVideos(channelID uint, fn func(row *models.VideoExportRow) error) error
+	StreamChannelSubscribers(channelID uint, fn func(row *models.SubscriberExportRow) error) error
+	StreamChannelComments(channelID uint, fn func(row *models.CommentExportRow) error) error
 	GetVideosByChannelID(id, page, limit int) ([]models.VideoDTO, int64, error)
 	DeleteVideoModel(*models.Video) error
+	TrashVideo(video *models.Video) error
+	FindTrashedVideo(id uint) (*models.Video, error)
+	RestoreVideo(id uint) error
+	GetTrashedVideos(userID uint) ([]models.TrashedVideoDTO, error)
+	GetExpiredTrashedVideos(before time.Time) ([]models.Video, error)
 	FindAllVideoIDByChannelID(id uint) ([]uint, error)
 	DeleteAllVideoIDByChannelID(id uint) error
 	DeleteVideoFromCloudinary(publicID string) error
-	CreateVideo(video *models.Video) (uint, error)
+	CreateVideo(video *models.Video, tags []string) (uint, error)
 	UpdateVideo(video *models.Video) error
 
 	GetCommentsByVideoID(id, page, limit int) ([]models.CommentDTO, int64, error)
@@ -33,9 +130,28 @@ type DatabaseRepo interface {
 	GetChannelByID(id int) (*models.CustomChannelDTO, error)
 	UpdateChannel(channel *models.CustomChannelDTO) error
 	DeleteChannelByID(id int) *models.CustomError
+	TrashChannel(channelID uint) error
+	FindTrashedChannel(id uint) (*models.Channel, error)
+	RestoreChannel(id uint) error
+	GetTrashedChannels(userID uint) ([]models.TrashedChannelDTO, error)
+	GetExpiredTrashedChannelIDs(before time.Time) ([]uint, error)
 	GetChannelsWithDetailsByUserID(userID uint) ([]models.CustomChannelDTO, error)
+	GetManagedChannelVideos(channelID uint, visibility, status string, page, limit int) ([]models.ChannelVideoDTO, int64, error)
 	GetVideosByChannelIDWithPagination(channelID uint, page, limit int) ([]models.VideoDTO, int64, error)
 	GetChannelWithDetails(channelID uint, userID uint) (*models.CustomChannelDTO, error)
+	FindChannelByHandle(handle string) (uint, string, error)
+	IsHandleAvailable(handle string, channelID uint) (bool, error)
+	UpdateChannelHandle(channelID uint, oldHandle, newHandle string, redirectUntil time.Time) error
+	GetChannelRole(channelID, userID uint) (string, error)
+	GetChannelMembers(channelID uint) ([]models.ChannelMemberDTO, error)
+	UpdateChannelMemberRole(channelID, userID uint, role string) error
+	DeleteChannelMember(channelID, userID uint) error
+	CreateChannelInvite(invite *models.ChannelInvite) error
+	GetChannelInvites(channelID uint) ([]models.ChannelInvite, error)
+	GetChannelInviteByToken(token string) (*models.ChannelInvite, error)
+	DeleteChannelInvite(channelID, inviteID uint) error
+	AcceptChannelInvite(invite *models.ChannelInvite, userID uint) error
+	TransferChannelOwnership(channelID, fromUserID, toUserID uint) error
 
 	GetLikeByVideoIDAndUserID(videoID, userID uint) (*models.Like, error)
 	CreateLike(like *models.Like) (uint, error)
@@ -44,6 +160,10 @@ type DatabaseRepo interface {
 
 	IsSubscribed(userID, channelID uint) bool
 	ToggleSubscription(userID, channelID uint) (uint, error)
+	GetSubscriptionFeed(userID uint, page, limit int) ([]models.VideoDTO, int64, error)
+	GetSubscribedChannelsByUserID(userID uint) ([]models.SubscribedChannelDTO, error)
+	MarkSubscriptionsAsSeen(userID uint) error
+	GetSubscriberIDsByChannelID(channelID uint) ([]uint, error)
 
 	GetNotificationsByUserID(userID uint, page, limit int) ([]models.Notification, int64, error)
 	GetUnreadNotificationsByUserID(userID uint) ([]models.Notification, error)
@@ -163,3 +163,95 @@ func (v *Validator) IsImageSize(imageSize, max int64, key string) {
 		v.AddError(key, "Invalid image size. Please upload a valid image size. Maximum size is 5MB")
 	}
 }
+
+// IsIn validates the data is one of the allowed values
+func (v *Validator) IsIn(data, key string, allowed []string, message string) {
+	for _, value := range allowed {
+		if data == value {
+			return
+		}
+	}
+	v.AddError(key, message)
+}
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9]+([ -][a-z0-9]+)*$`)
+
+// IsTags validates the number of tags and the characters of every tag
+func (v *Validator) IsTags(tags []string, key string, max int) {
+	if len(tags) > max {
+		v.AddError(key, fmt.Sprintf("You can add at most %d tags", max))
+		return
+	}
+
+	for _, tag := range tags {
+		if len(tag) < 2 || len(tag) > 30 || !tagPattern.MatchString(tag) {
+			v.AddError(key, fmt.Sprintf("Invalid tag %q. Tags must be between 2 and 30 characters and contain only letters, numbers, spaces and hyphens", tag))
+			return
+		}
+	}
+}
+
+// words that can not be used as a channel handle because they clash with the app routes or pages
+var reservedHandles = map[string]bool{
+	"admin": true, "administrator": true, "api": true, "auth": true, "categories": true, "channel": true,
+	"channels": true, "feed": true, "help": true, "home": true, "login": true, "logout": true, "me": true,
+	"moderator": true, "notifications": true, "official": true, "root": true, "settings": true, "signup": true,
+	"staff": true, "support": true, "system": true, "tags": true, "trending": true, "videos": true, "vidverse": true,
+}
+
+// words a channel handle can not contain
+var profaneWords = map[string]bool{
+	"fuck": true, "shit": true, "bitch": true, "cunt": true, "dick": true, "pussy": true,
+	"whore": true, "slut": true, "nigger": true, "faggot": true, "rape": true,
+}
+
+var (
+	handlePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{1,28}[a-z0-9]$`)
+	handleLetter  = regexp.MustCompile(`[a-z]`)
+	handleWords   = regexp.MustCompile(`[a-z]+`)
+)
+
+// IsHandle validates a channel handle, it must be lowercase already
+func (v *Validator) IsHandle(handle, key string) {
+	if !handlePattern.MatchString(handle) || strings.Contains(handle, "..") {
+		v.AddError(key, "Invalid handle. Handles must be between 3 and 30 characters and contain only letters, numbers, dots, hyphens and underscores")
+		return
+	}
+
+	// a handle made only of numbers could be mistaken for a channel ID
+	if !handleLetter.MatchString(handle) {
+		v.AddError(key, "Invalid handle. Handles must contain at least one letter")
+		return
+	}
+
+	if reservedHandles[handle] {
+		v.AddError(key, fmt.Sprintf("The handle @%s is reserved. Please choose another one", handle))
+		return
+	}
+
+	// only whole words are compared so innocent words containing one are allowed,
+	// the letters are also joined so a word can not be hidden by splitting it
+	words := handleWords.FindAllString(handle, -1)
+	words = append(words, strings.Join(words, ""))
+	for _, word := range words {
+		if profaneWords[word] {
+			v.AddError(key, "The handle contains a word that is not allowed. Please choose another one")
+			return
+		}
+	}
+}
+
+// IsImageDimensions validates an image is large enough and close to the expected aspect ratio
+func (v *Validator) IsImageDimensions(width, height int, key string, minWidth, minHeight int, ratioWidth, ratioHeight int) {
+	if width < minWidth || height < minHeight {
+		v.AddError(key, fmt.Sprintf("%s must be at least %dx%d pixels", key, minWidth, minHeight))
+		return
+	}
+
+	// allow a 2% difference so images resized by hand are not rejected
+	ratio := float64(width) / float64(height)
+	expected := float64(ratioWidth) / float64(ratioHeight)
+	if ratio < expected*0.98 || ratio > expected*1.02 {
+		v.AddError(key, fmt.Sprintf("%s must have a %d:%d aspect ratio", key, ratioWidth, ratioHeight))
+	}
+}
@@ -0,0 +1,101 @@
+package validator
+
+import "testing"
+
+func TestIsTags(t *testing.T) {
+	tests := []struct {
+		name  string
+		tags  []string
+		max   int
+		valid bool
+	}{
+		{name: "no tags", tags: nil, max: 3, valid: true},
+		{name: "valid tags", tags: []string{"go", "web dev", "how-to"}, max: 3, valid: true},
+		{name: "too many tags", tags: []string{"aa", "bb", "cc", "dd"}, max: 3, valid: false},
+		{name: "too short", tags: []string{"a"}, max: 3, valid: false},
+		{name: "too long", tags: []string{"abcdefghijklmnopqrstuvwxyz12345"}, max: 3, valid: false},
+		{name: "upper case", tags: []string{"Go"}, max: 3, valid: false},
+		{name: "leading separator", tags: []string{"-go"}, max: 3, valid: false},
+		{name: "double separator", tags: []string{"web  dev"}, max: 3, valid: false},
+		{name: "symbols", tags: []string{"c++"}, max: 3, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := New()
+			v.IsTags(tt.tags, "tags", tt.max)
+			if v.Valid() != tt.valid {
+				t.Errorf("IsTags(%q) valid = %v, want %v (%s)", tt.tags, v.Valid(), tt.valid, v.GetErrMsg())
+			}
+		})
+	}
+}
+
+func TestIsHandle(t *testing.T) {
+	tests := []struct {
+		name   string
+		handle string
+		valid  bool
+	}{
+		{name: "letters", handle: "vidfan", valid: true},
+		{name: "letters, numbers and separators", handle: "go.dev-2024_clips", valid: true},
+		{name: "too short", handle: "ab", valid: false},
+		{name: "too long", handle: "abcdefghijklmnopqrstuvwxyz12345", valid: false},
+		{name: "leading separator", handle: ".vidfan", valid: false},
+		{name: "trailing separator", handle: "vidfan_", valid: false},
+		{name: "double dot", handle: "vid..fan", valid: false},
+		{name: "upper case", handle: "VidFan", valid: false},
+		{name: "only numbers", handle: "12345", valid: false},
+		{name: "only numbers and separators", handle: "123-456", valid: false},
+		{name: "reserved", handle: "admin", valid: false},
+		{name: "profane word", handle: "shit", valid: false},
+		{name: "profane word between separators", handle: "the.shit.show", valid: false},
+		{name: "profane word next to numbers", handle: "shit42", valid: false},
+		{name: "profane word split by separators", handle: "s.h.i.t", valid: false},
+		{name: "innocent word containing a profane one", handle: "scunthorpe", valid: true},
+		{name: "another innocent word", handle: "therapist", valid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := New()
+			v.IsHandle(tt.handle, "handle")
+			if v.Valid() != tt.valid {
+				t.Errorf("IsHandle(%q) valid = %v, want %v (%s)", tt.handle, v.Valid(), tt.valid, v.GetErrMsg())
+			}
+		})
+	}
+}
+
+func TestIsImageDimensions(t *testing.T) {
+	tests := []struct {
+		name        string
+		width       int
+		height      int
+		minWidth    int
+		minHeight   int
+		ratioWidth  int
+		ratioHeight int
+		valid       bool
+	}{
+		{name: "16:9 cover", width: 2048, height: 1152, minWidth: 2048, minHeight: 1152, ratioWidth: 16, ratioHeight: 9, valid: true},
+		{name: "larger 16:9 cover", width: 2560, height: 1440, minWidth: 2048, minHeight: 1152, ratioWidth: 16, ratioHeight: 9, valid: true},
+		{name: "cover resized by hand", width: 2560, height: 1430, minWidth: 2048, minHeight: 1152, ratioWidth: 16, ratioHeight: 9, valid: true},
+		{name: "too narrow", width: 2000, height: 1125, minWidth: 2048, minHeight: 1152, ratioWidth: 16, ratioHeight: 9, valid: false},
+		{name: "too short", width: 4096, height: 1000, minWidth: 2048, minHeight: 1152, ratioWidth: 16, ratioHeight: 9, valid: false},
+		{name: "4:3 cover", width: 2048, height: 1536, minWidth: 2048, minHeight: 1152, ratioWidth: 16, ratioHeight: 9, valid: false},
+		{name: "square watermark", width: 150, height: 150, minWidth: 150, minHeight: 150, ratioWidth: 1, ratioHeight: 1, valid: true},
+		{name: "wide watermark", width: 300, height: 150, minWidth: 150, minHeight: 150, ratioWidth: 1, ratioHeight: 1, valid: false},
+		{name: "empty image", width: 0, height: 0, minWidth: 150, minHeight: 150, ratioWidth: 1, ratioHeight: 1, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := New()
+			v.IsImageDimensions(tt.width, tt.height, "image", tt.minWidth, tt.minHeight, tt.ratioWidth, tt.ratioHeight)
+			if v.Valid() != tt.valid {
+				t.Errorf("IsImageDimensions(%d, %d) valid = %v, want %v (%s)", tt.width, tt.height, v.Valid(), tt.valid, v.GetErrMsg())
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/raihan2bd/vidverse/helpers"
+)
+
+// PurgeAccounts delete for good the accounts whose deletion grace period is over
+func (m *Repo) PurgeAccounts() {
+	interval := envDuration("ACCOUNT_PURGE_MINUTES", time.Minute, 60)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.purgeAccounts(time.Now())
+		<-ticker.C
+	}
+}
+
+func (m *Repo) purgeAccounts(now time.Time) {
+	users, err := m.App.DBMethods.GetAccountsDueForDeletion(now)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, user := range users {
+		// the channels of the user go with their videos and files
+		channelIDs, err := m.App.DBMethods.GetOwnedChannelIDs(user.ID)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		failed := false
+		for _, id := range channelIDs {
+			customErr := m.App.DBMethods.DeleteChannelByID(int(id))
+			if customErr != nil {
+				log.Println(customErr.Err)
+				failed = true
+			}
+		}
+		if failed {
+			continue
+		}
+
+		exports, err := m.App.DBMethods.GetDataExports(user.ID)
+		if err == nil {
+			removeDataExportFiles(exports)
+		}
+
+		err = m.App.DBMethods.DeleteUserAccount(user.ID)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if user.AvatarPublicID != "" {
+			err = helpers.DeleteImageFromCloudinary(context.Background(), m.App.CLD, user.AvatarPublicID)
+			if err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package workers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/raihan2bd/vidverse/config"
+	"github.com/raihan2bd/vidverse/models"
+	"github.com/raihan2bd/vidverse/repository"
+)
+
+// a database recording the calls of the account purge, the other methods are not used by it
+type purgeDB struct {
+	repository.DatabaseRepo
+	dueBefore      time.Time
+	users          []models.User
+	channels       map[uint][]uint
+	failedChannels map[uint]bool
+	calls          []string
+}
+
+func (db *purgeDB) GetAccountsDueForDeletion(now time.Time) ([]models.User, error) {
+	db.dueBefore = now
+	return db.users, nil
+}
+
+func (db *purgeDB) GetOwnedChannelIDs(userID uint) ([]uint, error) {
+	return db.channels[userID], nil
+}
+
+func (db *purgeDB) DeleteChannelByID(id int) *models.CustomError {
+	db.calls = append(db.calls, fmt.Sprintf("channel %d", id))
+	if db.failedChannels[uint(id)] {
+		return &models.CustomError{Status: 500, Err: errors.New("failed to delete the channel")}
+	}
+	return nil
+}
+
+func (db *purgeDB) GetDataExports(userID uint) ([]models.DataExport, error) {
+	return nil, nil
+}
+
+func (db *purgeDB) DeleteUserAccount(userID uint) error {
+	db.calls = append(db.calls, fmt.Sprintf("user %d", userID))
+	return nil
+}
+
+func dueUser(id uint) models.User {
+	var user models.User
+	user.ID = id
+	return user
+}
+
+func TestPurgeAccounts(t *testing.T) {
+	tests := []struct {
+		name           string
+		users          []models.User
+		channels       map[uint][]uint
+		failedChannels map[uint]bool
+		want           []string
+	}{
+		{
+			name: "no accounts due",
+		},
+		{
+			name:  "channels go before the account",
+			users: []models.User{dueUser(1)},
+			channels: map[uint][]uint{
+				1: {10, 11},
+			},
+			want: []string{"channel 10", "channel 11", "user 1"},
+		},
+		{
+			name:  "account without channels",
+			users: []models.User{dueUser(2)},
+			want:  []string{"user 2"},
+		},
+		{
+			name:  "an account is kept when one of its channels can not be deleted",
+			users: []models.User{dueUser(1), dueUser(2)},
+			channels: map[uint][]uint{
+				1: {10, 11},
+				2: {20},
+			},
+			failedChannels: map[uint]bool{10: true},
+			want:           []string{"channel 10", "channel 11", "channel 20", "user 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &purgeDB{users: tt.users, channels: tt.channels, failedChannels: tt.failedChannels}
+			m := NewAPP(&config.Application{DBMethods: db})
+
+			now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+			m.purgeAccounts(now)
+
+			if !db.dueBefore.Equal(now) {
+				t.Errorf("GetAccountsDueForDeletion() got %v, want %v", db.dueBefore, now)
+			}
+			if !reflect.DeepEqual(db.calls, tt.want) {
+				t.Errorf("purgeAccounts() deleted %q, want %q", db.calls, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package workers
+
+import (
+	"log"
+	"time"
+)
+
+// RollupAnalytics roll the analytics events up into the daily channel stats
+func (m *Repo) RollupAnalytics() {
+	interval := envDuration("ANALYTICS_ROLLUP_MINUTES", time.Minute, 15)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := m.App.DBMethods.RollupAnalytics()
+		if err != nil {
+			log.Println(err)
+		}
+		<-ticker.C
+	}
+}
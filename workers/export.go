@@ -0,0 +1,175 @@
+package workers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// how long a ready data export can be downloaded
+const dataExportTTL = 7 * 24 * time.Hour
+
+// the directory the ZIP files of the data exports are written to
+func dataExportDir() string {
+	dir := os.Getenv("DATA_EXPORT_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "vidverse-exports")
+	}
+	return dir
+}
+
+// BuildDataExports build the requested data exports and remove the expired ones
+func (m *Repo) BuildDataExports() {
+	interval := envDuration("DATA_EXPORT_MINUTES", time.Minute, 1)
+
+	// the exports being built when the server stopped would stay processing forever
+	err := m.App.DBMethods.ResetUnfinishedDataExports()
+	if err != nil {
+		log.Println(err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.buildDataExports()
+		m.removeExpiredDataExports()
+		<-ticker.C
+	}
+}
+
+func (m *Repo) buildDataExports() {
+	exports, err := m.App.DBMethods.GetPendingDataExports()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for i := range exports {
+		export := &exports[i]
+		export.Status = models.ExportProcessing
+		err = m.App.DBMethods.UpdateDataExport(export)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		path, err := m.writeDataExport(export)
+		now := time.Now()
+		if err != nil {
+			log.Println(err)
+			export.Status = models.ExportFailed
+			export.Error = "Failed to build the export. Please request a new one"
+		} else {
+			expiresAt := now.Add(dataExportTTL)
+			export.Status = models.ExportReady
+			export.FilePath = path
+			export.ExpiresAt = &expiresAt
+		}
+		export.CompletedAt = &now
+
+		err = m.App.DBMethods.UpdateDataExport(export)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// write the data of a user as JSON files in a ZIP
+func (m *Repo) writeDataExport(export *models.DataExport) (string, error) {
+	data, err := m.App.DBMethods.GetUserData(export.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	dir := dataExportDir()
+	err = os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("export-%d-%d.zip", export.UserID, export.ID))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", data.Profile},
+		{"channels.json", data.Channels},
+		{"videos.json", data.Videos},
+		{"comments.json", data.Comments},
+		{"likes.json", data.Likes},
+		{"subscriptions.json", data.Subscriptions},
+		{"notifications.json", data.Notifications},
+	}
+
+	archive := zip.NewWriter(file)
+	for _, f := range files {
+		w, err := archive.Create(f.name)
+		if err == nil {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			err = encoder.Encode(f.content)
+		}
+		if err != nil {
+			archive.Close()
+			file.Close()
+			os.Remove(path)
+			return "", err
+		}
+	}
+
+	err = archive.Close()
+	if err == nil {
+		err = file.Close()
+	} else {
+		file.Close()
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+
+	return path, nil
+}
+
+func (m *Repo) removeExpiredDataExports() {
+	exports, err := m.App.DBMethods.GetExpiredDataExports(time.Now())
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	removeDataExportFiles(exports)
+
+	for _, export := range exports {
+		err = m.App.DBMethods.DeleteDataExport(export.ID)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// remove the ZIP files of data exports
+func removeDataExportFiles(exports []models.DataExport) {
+	for _, export := range exports {
+		if export.FilePath == "" {
+			continue
+		}
+
+		err := os.Remove(export.FilePath)
+		if err != nil && !os.IsNotExist(err) {
+			log.Println(err)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/raihan2bd/vidverse/config"
+	"github.com/raihan2bd/vidverse/helpers"
+	"github.com/raihan2bd/vidverse/internal/media"
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// VideoStatusEvent is sent to the uploader every time the status of the video changes
+type VideoStatusEvent struct {
+	VideoID uint   `json:"video_id"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// ProcessVideos start the workers processing the uploaded videos
+func (m *Repo) ProcessVideos() {
+	// the jobs of unfinished videos are gone after a restart
+	err := m.App.DBMethods.FailUnfinishedVideos("The server restarted while processing the video. Please upload it again")
+	if err != nil {
+		log.Println(err)
+	}
+
+	// so are the temporary files they left behind
+	removeTempUploads()
+
+	workers := config.EnvInt("VIDEO_WORKERS", 2)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range m.App.VideoJobChan {
+				m.processVideo(job)
+			}
+		}()
+	}
+}
+
+func (m *Repo) processVideo(job *config.VideoJob) {
+	defer os.Remove(job.VideoPath)
+	if job.ThumbPath != "" {
+		defer os.Remove(job.ThumbPath)
+	}
+
+	ctx := context.Background()
+	video := models.Video{CustomModel: models.CustomModel{ID: job.VideoID}}
+
+	// the duration was probed when the video was uploaded, a replaced video still has its previous files
+	var (
+		duration                           float64
+		oldVideoPublicID, oldThumbPublicID string
+	)
+	if saved, err := m.App.DBMethods.FindVideoByID(job.VideoID); err == nil {
+		duration = saved.Duration
+		oldVideoPublicID, oldThumbPublicID = saved.PublicID, saved.ThumbPublicID
+	}
+
+	// upload video to cloudinary
+	videoFile, err := os.Open(job.VideoPath)
+	if err != nil {
+		m.failVideo(job, "Failed to read the uploaded video")
+		return
+	}
+	video.SecureURL, video.PublicID, err = helpers.UploadVideoToCloudinary(ctx, m.App.CLD, videoFile)
+	videoFile.Close()
+	if err != nil {
+		m.failVideo(job, "Failed to upload the video")
+		return
+	}
+
+	m.setVideoStatus(job, models.StatusProcessing, "")
+
+	// render the candidate thumbnails, fallback to the one cloudinary generates
+	thumbnails := m.generateThumbnails(ctx, job, duration)
+	video.Thumb = helpers.GenerateVideoThumbURL(m.App.CLD, video.PublicID)
+	for _, thumbnail := range thumbnails {
+		if thumbnail.IsSelected {
+			video.Thumb, video.ThumbSmall, video.ThumbLarge = thumbnail.Medium, thumbnail.Small, thumbnail.Large
+		}
+	}
+
+	// the candidates of a replaced file are swapped for the new ones
+	replaced, err := m.App.DBMethods.UpdateVideoAssets(&video, thumbnails)
+	if err != nil {
+		for i := range thumbnails {
+			helpers.DeleteThumbnailFromCloudinary(ctx, m.App.CLD, &thumbnails[i])
+		}
+		_ = helpers.DeleteVideoFromCloudinary(ctx, m.App.CLD, video.PublicID)
+		m.failVideo(job, "Failed to save the video")
+		return
+	}
+
+	for i := range replaced {
+		helpers.DeleteThumbnailFromCloudinary(ctx, m.App.CLD, &replaced[i])
+	}
+
+	if oldVideoPublicID != "" {
+		_ = helpers.DeleteVideoFromCloudinary(ctx, m.App.CLD, oldVideoPublicID)
+	}
+	if oldThumbPublicID != "" {
+		_ = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, oldThumbPublicID)
+	}
+
+	m.setVideoStatus(job, models.StatusReady, "")
+
+	// only the first time the video goes live, not when the file of a live video is replaced
+	saved, err := m.App.DBMethods.FindVideoByID(job.VideoID)
+	if err == nil && saved.Visibility == models.VisibilityPublic && !job.WasPublished {
+		helpers.NotifySubscribers(m.App, job.VideoID)
+	}
+
+	// the video is already playable, the previews are only a nice to have
+	err = m.generatePreview(ctx, job, duration)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// render the animated hover preview and the seek-bar sprite sheet with its WebVTT track
+func (m *Repo) generatePreview(ctx context.Context, job *config.VideoJob, duration float64) error {
+	preview := models.VideoPreview{VideoID: job.VideoID}
+
+	previewPath, err := media.GeneratePreview(ctx, job.VideoPath, duration, job.VideoPath+"-preview")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(previewPath)
+
+	spritePath := job.VideoPath + "-sprite.jpg"
+	sprite, err := media.GenerateSprite(ctx, job.VideoPath, duration, spritePath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(spritePath)
+
+	preview.PreviewURL, preview.PreviewPublicID, err = uploadFile(ctx, m, previewPath, "vidverse/uploads/previews")
+	if err != nil {
+		return err
+	}
+
+	preview.SpriteURL, preview.SpritePublicID, err = uploadFile(ctx, m, spritePath, "vidverse/uploads/sprites")
+	if err != nil {
+		_ = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, preview.PreviewPublicID)
+		return err
+	}
+
+	preview.SpriteInterval = sprite.Interval
+	preview.ThumbnailTrack = media.BuildThumbnailTrack(preview.SpriteURL, sprite, duration)
+
+	old, _ := m.App.DBMethods.GetVideoPreview(job.VideoID)
+
+	err = m.App.DBMethods.SaveVideoPreview(&preview)
+	if err != nil {
+		_ = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, preview.PreviewPublicID)
+		_ = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, preview.SpritePublicID)
+		return err
+	}
+
+	// the video was processed before, remove the files of the replaced preview
+	if old != nil {
+		_ = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, old.PreviewPublicID)
+		_ = helpers.DeleteImageFromCloudinary(ctx, m.App.CLD, old.SpritePublicID)
+	}
+
+	return nil
+}
+
+// upload an image from disk to cloudinary
+func uploadFile(ctx context.Context, m *Repo, path, folder string) (string, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	return helpers.UploadImageToCloudinary(ctx, m.App.CLD, file, folder)
+}
+
+// store the uploaded thumb and a few frames of the video as candidate thumbnails
+func (m *Repo) generateThumbnails(ctx context.Context, job *config.VideoJob, duration float64) []models.VideoThumbnail {
+	var thumbnails []models.VideoThumbnail
+
+	if job.ThumbPath != "" {
+		thumbnail, err := helpers.StoreThumbnail(ctx, m.App.CLD, job.ThumbPath)
+		if err != nil {
+			log.Println(err)
+		} else {
+			thumbnail.IsCustom = true
+			thumbnails = append(thumbnails, *thumbnail)
+		}
+	}
+
+	for i, timestamp := range media.FrameTimestamps(duration, config.EnvInt("THUMBNAIL_FRAMES", 4)) {
+		framePath := fmt.Sprintf("%s-frame-%d.jpg", job.VideoPath, i)
+		err := media.ExtractFrame(ctx, job.VideoPath, timestamp, framePath)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		thumbnail, err := helpers.StoreThumbnail(ctx, m.App.CLD, framePath)
+		os.Remove(framePath)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		thumbnail.Timestamp = timestamp
+		thumbnails = append(thumbnails, *thumbnail)
+	}
+
+	if len(thumbnails) == 0 {
+		return thumbnails
+	}
+
+	// the uploaded thumb wins, otherwise the frame from the middle of the video
+	selected := len(thumbnails) / 2
+	if thumbnails[0].IsCustom {
+		selected = 0
+	}
+	thumbnails[selected].IsSelected = true
+
+	for i := range thumbnails {
+		thumbnails[i].VideoID = job.VideoID
+	}
+
+	return thumbnails
+}
+
+// remove the uploaded files and their renders left in the upload dir by a previous run, the dir only holds files of the app
+func removeTempUploads() {
+	entries, err := os.ReadDir(helpers.UploadDir())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Println(err)
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(helpers.UploadDir(), entry.Name())); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+func (m *Repo) failVideo(job *config.VideoJob, message string) {
+	m.setVideoStatus(job, models.StatusFailed, message)
+}
+
+// save the new status and let the uploader know about it
+func (m *Repo) setVideoStatus(job *config.VideoJob, status, message string) {
+	err := m.App.DBMethods.UpdateVideoStatus(job.VideoID, status, message)
+	if err != nil {
+		log.Println(err)
+	}
+
+	m.App.NotificationChan <- &config.NotificationEvent{
+		BroadcasterID: job.UserID,
+		Action:        "video_status",
+		Data:          &VideoStatusEvent{VideoID: job.VideoID, Status: status, Message: message},
+	}
+
+	video, err := m.App.DBMethods.FindVideoByID(job.VideoID)
+	if err == nil {
+		helpers.DispatchWebhookEvent(m.App, video.ChannelID, models.EventVideoStatusChanged, &VideoStatusEvent{VideoID: job.VideoID, Status: status, Message: message})
+	}
+}
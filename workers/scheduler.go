@@ -0,0 +1,43 @@
+package workers
+
+import (
+	"log"
+	"time"
+
+	"github.com/raihan2bd/vidverse/helpers"
+)
+
+// PublishScheduledVideos publish the scheduled videos once their publish time has come
+func (m *Repo) PublishScheduledVideos() {
+	interval := envDuration("SCHEDULER_INTERVAL_SECONDS", time.Second, 60)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.publishScheduledVideos()
+		<-ticker.C
+	}
+}
+
+func (m *Repo) publishScheduledVideos() {
+	now := time.Now()
+
+	videos, err := m.App.DBMethods.GetDueScheduledVideos(now)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, video := range videos {
+		published, err := m.App.DBMethods.PublishScheduledVideo(video.ID, now)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if published {
+			helpers.NotifySubscribers(m.App, video.ID)
+		}
+	}
+}
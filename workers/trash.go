@@ -0,0 +1,50 @@
+package workers
+
+import (
+	"log"
+	"time"
+)
+
+// PurgeTrash delete the channels and videos whose time in the trash is over, with their files
+func (m *Repo) PurgeTrash() {
+	interval := envDuration("TRASH_PURGE_MINUTES", time.Minute, 60)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.purgeTrash(time.Now().Add(-m.App.TrashRetention))
+		<-ticker.C
+	}
+}
+
+func (m *Repo) purgeTrash(before time.Time) {
+	// the channels go first as they take their videos with them
+	channelIDs, err := m.App.DBMethods.GetExpiredTrashedChannelIDs(before)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, id := range channelIDs {
+		customErr := m.App.DBMethods.DeleteChannelByID(int(id))
+		if customErr != nil {
+			log.Println(customErr.Err)
+		}
+	}
+
+	videos, err := m.App.DBMethods.GetExpiredTrashedVideos(before)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for i := range videos {
+		err = m.App.DBMethods.DeleteVideoModel(&videos[i])
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		m.App.Recommender.Invalidate(videos[i].ID)
+	}
+}
@@ -0,0 +1,80 @@
+package workers
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// weights and decay used to compute the trending score
+const (
+	viewWeight    = 1.0
+	likeWeight    = 4.0
+	commentWeight = 6.0
+	gravity       = 1.5
+)
+
+// RefreshTrending recompute the trending table on every tick
+func (m *Repo) RefreshTrending() {
+	window := envDuration("TRENDING_WINDOW_HOURS", time.Hour, 48)
+	interval := envDuration("TRENDING_REFRESH_MINUTES", time.Minute, 15)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := m.refreshTrending(window)
+		if err != nil {
+			log.Println(err)
+		}
+		<-ticker.C
+	}
+}
+
+func (m *Repo) refreshTrending(window time.Duration) error {
+	now := time.Now()
+
+	stats, err := m.App.DBMethods.GetTrendingStats(now.Add(-window))
+	if err != nil {
+		return err
+	}
+
+	videos := make([]models.TrendingVideo, 0, len(stats))
+	for _, stat := range stats {
+		velocity := viewVelocity(stat, window)
+		videos = append(videos, models.TrendingVideo{
+			VideoID:       stat.VideoID,
+			Score:         trendingScore(stat, velocity, window, now),
+			ViewsSnapshot: stat.Views,
+			ViewVelocity:  velocity,
+			UpdatedAt:     now,
+		})
+	}
+
+	return m.App.DBMethods.ReplaceTrendingVideos(videos)
+}
+
+// views per hour in the window
+func viewVelocity(stat models.TrendingStat, window time.Duration) float64 {
+	return float64(stat.RecentViews) / window.Hours()
+}
+
+// activity per hour in the window, decayed by the age of the video
+func trendingScore(stat models.TrendingStat, velocity float64, window time.Duration, now time.Time) float64 {
+	activity := viewWeight*velocity +
+		likeWeight*float64(stat.RecentLikes)/window.Hours() +
+		commentWeight*float64(stat.RecentComments)/window.Hours()
+
+	if activity <= 0 {
+		return 0
+	}
+
+	age := now.Sub(stat.CreatedAt).Hours()
+	if age < 0 {
+		age = 0
+	}
+
+	return activity / math.Pow(age+2, gravity)
+}
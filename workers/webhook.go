@@ -0,0 +1,113 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/raihan2bd/vidverse/config"
+	"github.com/raihan2bd/vidverse/internal/webhook"
+	"github.com/raihan2bd/vidverse/models"
+)
+
+// the longest wait between two attempts of a delivery
+const maxWebhookBackoff = 6 * time.Hour
+
+// how long a worker holds a delivery, longer than an attempt can take
+const webhookClaimLease = 5 * time.Minute
+
+// DeliverWebhooks start the workers sending the queued webhook events, the failed ones are retried with an exponential backoff
+func (m *Repo) DeliverWebhooks() {
+	interval := envDuration("WEBHOOK_POLL_SECONDS", time.Second, 10)
+	for i := 0; i < config.EnvInt("WEBHOOK_WORKERS", 4); i++ {
+		go m.webhookWorker(interval)
+	}
+
+	// the delivery logs are kept for a while
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		before := time.Now().Add(-envDuration("WEBHOOK_LOG_DAYS", 24*time.Hour, 30))
+		err := m.App.DBMethods.DeleteOldWebhookDeliveries(before)
+		if err != nil {
+			log.Println(err)
+		}
+
+		<-ticker.C
+	}
+}
+
+// send the due deliveries one at a time, a slow endpoint only holds up its own worker
+func (m *Repo) webhookWorker(interval time.Duration) {
+	for {
+		delivery, err := m.App.DBMethods.ClaimWebhookDelivery(time.Now(), webhookClaimLease)
+		if err != nil {
+			log.Println(err)
+		}
+		if delivery == nil {
+			time.Sleep(interval)
+			continue
+		}
+
+		m.attemptDelivery(delivery)
+	}
+}
+
+func (m *Repo) attemptDelivery(delivery *models.WebhookDelivery) {
+	delivery.Attempts++
+
+	hook, err := m.App.DBMethods.GetWebhookByID(delivery.WebhookID)
+	if err != nil || !hook.Active {
+		delivery.Status = models.DeliveryFailed
+		delivery.Error = "The webhook is disabled or deleted"
+		delivery.NextAttemptAt = nil
+		m.saveDelivery(delivery)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	result, err := webhook.Send(ctx, hook.URL, hook.Secret, delivery.Event, delivery.ID, []byte(delivery.Payload))
+	cancel()
+
+	delivery.ResponseStatus = result.Status
+
+	if err == nil {
+		now := time.Now()
+		delivery.Status = models.DeliverySucceeded
+		delivery.Error = ""
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+		m.saveDelivery(delivery)
+		return
+	}
+
+	delivery.Error = err.Error()
+	if len(delivery.Error) > 255 {
+		delivery.Error = delivery.Error[:255]
+	}
+
+	if delivery.Attempts >= config.EnvInt("WEBHOOK_MAX_ATTEMPTS", 8) {
+		delivery.Status = models.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		m.saveDelivery(delivery)
+		return
+	}
+
+	// every failed attempt doubles the wait before the next one
+	wait := envDuration("WEBHOOK_BACKOFF_SECONDS", time.Second, 30) << uint(delivery.Attempts-1)
+	if wait <= 0 || wait > maxWebhookBackoff {
+		wait = maxWebhookBackoff
+	}
+	next := time.Now().Add(wait)
+	delivery.Status = models.DeliveryPending
+	delivery.NextAttemptAt = &next
+	m.saveDelivery(delivery)
+}
+
+func (m *Repo) saveDelivery(delivery *models.WebhookDelivery) {
+	err := m.App.DBMethods.UpdateWebhookDelivery(delivery)
+	if err != nil {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,28 @@
+package workers
+
+import (
+	"time"
+
+	"github.com/raihan2bd/vidverse/config"
+)
+
+var Methods *Repo
+
+type Repo struct {
+	App *config.Application
+}
+
+func NewAPP(a *config.Application) *Repo {
+	return &Repo{
+		App: a,
+	}
+}
+
+func NewWorker(m *Repo) {
+	Methods = m
+}
+
+// read a duration in the given unit from the environment or fallback to the default value
+func envDuration(key string, unit time.Duration, fallback int) time.Duration {
+	return time.Duration(config.EnvInt(key, fallback)) * unit
+}